@@ -1,30 +1,71 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"net"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"log"
 
 	"github.com/j-griffith/populator/pkg/clientset/v1alpha1"
 	ctrl "github.com/j-griffith/populator/pkg/controller"
+	"github.com/j-griffith/populator/pkg/populator"
+	"github.com/j-griffith/populator/pkg/webhook"
+	batch "k8s.io/api/batch/v1"
 	api_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	papi "github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	papiv1beta1 "github.com/j-griffith/populator/pkg/api/types/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 )
 
-var kubeconfig string
+// componentName is used as the event source and the leader election lock name
+const componentName = "populator-controller"
+
+var (
+	kubeconfig              string
+	leaderElect             bool
+	leaderElectionNamespace string
+	webhookAddr             string
+	webhookCert             string
+	webhookKey              string
+	jobTemplateConfigMap    string
+	preflight               bool
+	downloadCacheClaim      string
+	downloadCacheLimit      string
+	watchNamespace          string
+	downloadCacheInterval   time.Duration
+)
+
+// populatorJobSelector matches the jobs BuildJobSpec creates
+const populatorJobSelector = "app=populator"
+
+// requestResync is how often PopulationRequests waiting for their PVC are looked at again
+const requestResync = 30 * time.Second
+
+// pvcRetries is how many times a PVC is retried after a failure that may clear up, one second doubling each time
+const pvcRetries = 8
 
 /*
 // getKubeConfig fetches our kubeconfig, we're not really doing anything here, if you passed a kubeconfig path in
@@ -71,10 +112,71 @@ func getKubernetesClient(cfg *rest.Config) kubernetes.Interface {
 func init() {
 	log.SetFlags(log.Ldate | log.Lmicroseconds | log.Lshortfile)
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "path to Kubernetes config file")
+	flag.BoolVar(&leaderElect, "leader-elect", false, "use a Lease to make sure only one controller replica is active")
+	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "", "namespace for the leader election Lease (defaults to $POD_NAMESPACE)")
+	flag.StringVar(&webhookAddr, "webhook-addr", ":9443", "address to serve the admission webhooks on")
+	flag.StringVar(&webhookCert, "webhook-tls-cert", "", "TLS certificate for the admission webhooks, webhooks are disabled if not set")
+	flag.StringVar(&webhookKey, "webhook-tls-key", "", "TLS private key for the admission webhooks")
+	flag.StringVar(&jobTemplateConfigMap, "job-template-configmap", "", "[namespace/]name of a ConfigMap with the default populator job template (namespace defaults to $POD_NAMESPACE)")
+	flag.Var(int32Flag{&populator.DefaultTTLSecondsAfterFinished}, "job-ttl", "seconds to keep finished populator jobs, unless the Populator says otherwise")
+	flag.Var(int32Flag{&populator.DefaultBackoffLimit}, "job-backoff-limit", "retries before a population is marked failed, unless the Populator says otherwise")
+	flag.Int64Var(&populator.DefaultActiveDeadlineSeconds, "job-active-deadline", populator.DefaultActiveDeadlineSeconds, "seconds a population may run before it's marked failed, unless the Populator says otherwise")
+	flag.BoolVar(&preflight, "preflight", false, "check every source can be reached before launching a populator job")
+	flag.DurationVar(&populator.PreflightTimeout, "preflight-timeout", populator.PreflightTimeout, "time limit for each pre-flight source check")
+	flag.Var(cidrsFlag{v: &populator.PreflightDeniedNets, add: true}, "preflight-deny-cidrs", "comma separated CIDRs pre-flight checks can't connect to, on top of the private ranges denied already (add the cluster's pod and service ranges if they aren't private)")
+	flag.Var(cidrsFlag{v: &populator.PreflightAllowedNets}, "preflight-allow-cidrs", "comma separated CIDRs pre-flight checks may connect to even though they're denied, e.g. a private git server")
+	flag.StringVar(&downloadCacheClaim, "download-cache-claim", "", "[namespace/]name of a ReadWriteMany PVC to keep downloads in between populations (namespace defaults to default), only PVCs in its namespace use it, no cache if not set")
+	flag.StringVar(&downloadCacheLimit, "download-cache-limit", "10Gi", "size the download cache is trimmed back to")
+	flag.DurationVar(&downloadCacheInterval, "download-cache-evict-interval", time.Hour, "how often to trim the download cache")
+	flag.BoolVar(&populator.AllowURLCredentials, "allow-url-credentials", false, "accept Populators with credentials in their git repo URL (deprecated, they're only warned about)")
+	flag.StringVar(&watchNamespace, "watch-namespace", metav1.NamespaceAll, "namespace to populate PVCs in, all namespaces if empty")
+	flag.StringVar(&populator.ClusterSecretNamespace, "cluster-secret-namespace", "", "namespace the secrets ClusterPopulators use are kept in (defaults to $POD_NAMESPACE)")
 	flag.Parse()
 
 }
 
+// newClassInformer returns an informer that keeps the populator package's PopulatorClass types in sync with the
+// cluster, there's nothing to queue since registering a class is all the work there is
+func newClassInformer(populatorClient v1alpha1.Interface) cache.SharedIndexInformer {
+	informer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return populatorClient.PopulatorClasses().List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return populatorClient.PopulatorClasses().Watch(context.TODO(), options)
+			},
+		},
+		&papi.PopulatorClass{},
+		0,
+		cache.Indexers{},
+	)
+	setClass := func(obj interface{}) {
+		class := obj.(*papi.PopulatorClass)
+		if err := populator.SetClass(class); err != nil {
+			log.Printf("ignoring PopulatorClass %s: %v", class.Name, err)
+			populator.RemoveClass(class.Name)
+			return
+		}
+		log.Printf("PopulatorClass %s registered", class.Name)
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: setClass,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			setClass(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if class, ok := obj.(*papi.PopulatorClass); ok {
+				populator.RemoveClass(class.Name)
+			}
+		},
+	})
+	return informer
+}
+
 // main code path
 func main() {
 	var cfg *rest.Config
@@ -94,6 +196,7 @@ func main() {
 	k8sClient := getKubernetesClient(cfg)
 	populatorClient := getPopulatorClient(cfg)
 	papi.AddToScheme(scheme.Scheme)
+	papiv1beta1.AddToScheme(scheme.Scheme)
 
 	/*
 		// get the Kubernetes client for connectivity
@@ -101,19 +204,19 @@ func main() {
 	*/
 
 	// create the informer so that we can not only list resources
-	// but also watch them for all PVCs in the default namespace
+	// but also watch them for all PVCs in the namespace we're watching (all of them if it's empty)
 	informer := cache.NewSharedIndexInformer(
 		// the ListWatch contains two different functions that our
 		// informer requires: ListFunc to take care of listing and watching
 		// the resources we want to handle
 		&cache.ListWatch{
 			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
-				// list all of the pvcs (core resource) in the deafult namespace
-				return k8sClient.CoreV1().PersistentVolumeClaims(metav1.NamespaceDefault).List(options)
+				// list all of the pvcs (core resource) in the watched namespace
+				return k8sClient.CoreV1().PersistentVolumeClaims(watchNamespace).List(context.TODO(), options)
 			},
 			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-				// watch all of the pvcs (core resource) in the default namespace
-				return k8sClient.CoreV1().PersistentVolumeClaims(metav1.NamespaceDefault).Watch(options)
+				// watch all of the pvcs (core resource) in the watched namespace
+				return k8sClient.CoreV1().PersistentVolumeClaims(watchNamespace).Watch(context.TODO(), options)
 			},
 		},
 		&api_v1.PersistentVolumeClaim{}, // the target type (PVC)
@@ -124,7 +227,9 @@ func main() {
 	// create a new queue so that when the informer gets a resource that is either
 	// a result of listing or watching, we can add an idenfitying key to the queue
 	// so that it can be handled in the handler
-	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	// PVCs are retried when their sources can't be reached, backing off from a second so a source that's down for
+	// a few minutes can come back before the population is failed (pvcRetries retries take about four minutes)
+	queue := workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Second, 5*time.Minute))
 
 	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
@@ -138,10 +243,15 @@ func main() {
 			}
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
-			// We only care about DataSource updates in this controller, so filter out anything that's not updating/adding a DataSource entry and move along
+			// We only care about DataSource updates in this controller, plus the population status and phase
+			// changes the Populator cache waits on (golden PVCs finishing, prime PVCs binding) and refresh
+			// requests, so filter out anything else and move along
 			origPVC, _ := oldObj.(*api_v1.PersistentVolumeClaim)
 			updatedPVC, _ := newObj.(*api_v1.PersistentVolumeClaim)
-			if origPVC.Spec.DataSource != updatedPVC.Spec.DataSource {
+			if !equality.Semantic.DeepEqual(origPVC.Spec.DataSource, updatedPVC.Spec.DataSource) ||
+				origPVC.Annotations[papi.AnnPopulationStatus] != updatedPVC.Annotations[papi.AnnPopulationStatus] ||
+				origPVC.Status.Phase != updatedPVC.Status.Phase ||
+				origPVC.Annotations[papi.AnnRefresh] != updatedPVC.Annotations[papi.AnnRefresh] {
 				key, err := cache.MetaNamespaceKeyFunc(newObj)
 				log.Printf("Update PVC: %s", key)
 				if err == nil {
@@ -163,14 +273,152 @@ func main() {
 		},
 	})
 
+	// events are recorded against the PVCs we populate so users can see what
+	// happened with a simple `kubectl describe pvc`
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartLogging(log.Printf)
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: k8sClient.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, api_v1.EventSource{Component: componentName})
+
 	// construct the Controller object which has all of the necessary components to
 	// handle logging, connections, informing (listing and watching), the queue,
 	// and the handler
+	handler := &ctrl.PopulatorHandler{KubeClient: k8sClient, PopulatorClient: populatorClient, Recorder: recorder, Preflight: preflight}
+	if handler.DynamicClient, err = dynamic.NewForConfig(cfg); err != nil {
+		log.Fatalf("unable to create dynamic client: %v", err)
+	}
+	if jobTemplateConfigMap != "" {
+		handler.JobTemplateNamespace, handler.JobTemplateConfigMap, err = cache.SplitMetaNamespaceKey(jobTemplateConfigMap)
+		if err != nil {
+			log.Fatalf("invalid -job-template-configmap: %v", err)
+		}
+		if handler.JobTemplateNamespace == "" {
+			handler.JobTemplateNamespace = os.Getenv("POD_NAMESPACE")
+		}
+	}
+	if populator.ClusterSecretNamespace == "" {
+		populator.ClusterSecretNamespace = os.Getenv("POD_NAMESPACE")
+	}
+	if populator.ClusterSecretNamespace == "" {
+		populator.ClusterSecretNamespace = metav1.NamespaceDefault
+	}
+	var evictor *ctrl.DownloadCacheEvictor
+	if downloadCacheClaim != "" {
+		ns, name, err := cache.SplitMetaNamespaceKey(downloadCacheClaim)
+		if err != nil {
+			log.Fatalf("invalid -download-cache-claim: %v", err)
+		}
+		if ns != "" {
+			populator.DownloadCacheNamespace = ns
+		}
+		populator.DefaultDownloadCacheClaim = name
+		if watchNamespace != populator.DownloadCacheNamespace {
+			log.Printf("download cache %s is only used by PVCs in namespace %s", downloadCacheClaim, populator.DownloadCacheNamespace)
+		}
+		limit, err := resource.ParseQuantity(downloadCacheLimit)
+		if err != nil {
+			log.Fatalf("invalid -download-cache-limit: %v", err)
+		}
+		evictor = &ctrl.DownloadCacheEvictor{
+			KubeClient: k8sClient,
+			Namespace:  populator.DownloadCacheNamespace,
+			Claim:      populator.DefaultDownloadCacheClaim,
+			LimitBytes: limit.Value(),
+			Interval:   downloadCacheInterval,
+		}
+	}
+	scheduler := &ctrl.RefreshScheduler{
+		KubeClient:      k8sClient,
+		PopulatorClient: populatorClient,
+		Namespace:       watchNamespace,
+		Interval:        time.Minute,
+	}
 	controller := ctrl.Controller{
 		ClientSet:          k8sClient,
 		Informer:           informer,
 		Queue:              queue,
-		Handler:            &ctrl.PopulatorHandler{KubeClient: k8sClient, PopulatorClient: populatorClient},
+		Handler:            handler,
+		PopulatorClientSet: populatorClient,
+		MaxRetries:         pvcRetries,
+	}
+
+	// a second informer watches the jobs we launch so we can record the outcome on the PVC, only job
+	// status changes matter here
+	jobInformer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.LabelSelector = populatorJobSelector
+				return k8sClient.BatchV1().Jobs(watchNamespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.LabelSelector = populatorJobSelector
+				return k8sClient.BatchV1().Jobs(watchNamespace).Watch(context.TODO(), options)
+			},
+		},
+		&batch.Job{},
+		0,
+		cache.Indexers{},
+	)
+	jobQueue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	jobInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if key, err := cache.MetaNamespaceKeyFunc(obj); err == nil {
+				jobQueue.Add(key)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldJob, _ := oldObj.(*batch.Job)
+			newJob, _ := newObj.(*batch.Job)
+			if !equality.Semantic.DeepEqual(oldJob.Status.Conditions, newJob.Status.Conditions) {
+				if key, err := cache.MetaNamespaceKeyFunc(newObj); err == nil {
+					jobQueue.Add(key)
+				}
+			}
+		},
+	})
+	jobController := ctrl.Controller{
+		ClientSet:          k8sClient,
+		Informer:           jobInformer,
+		Queue:              jobQueue,
+		Handler:            &ctrl.JobHandler{KubeClient: k8sClient, PopulatorClient: populatorClient, Recorder: recorder},
+		PopulatorClientSet: populatorClient,
+	}
+
+	// PopulationRequests are run once each, the resync picks up requests that are waiting for their PVC to be
+	// free, there's nothing else to wake them up
+	requestInformer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return populatorClient.PopulationRequests(watchNamespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return populatorClient.PopulationRequests(watchNamespace).Watch(context.TODO(), options)
+			},
+		},
+		&papi.PopulationRequest{},
+		requestResync,
+		cache.Indexers{},
+	)
+	requestQueue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	requestInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if key, err := cache.MetaNamespaceKeyFunc(obj); err == nil {
+				requestQueue.Add(key)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if r, _ := newObj.(*papi.PopulationRequest); r != nil && !r.Finished() {
+				if key, err := cache.MetaNamespaceKeyFunc(newObj); err == nil {
+					requestQueue.Add(key)
+				}
+			}
+		},
+	})
+	requestController := ctrl.Controller{
+		ClientSet:          k8sClient,
+		Informer:           requestInformer,
+		Queue:              requestQueue,
+		Handler:            &ctrl.RequestHandler{PopulatorHandler: handler},
 		PopulatorClientSet: populatorClient,
 	}
 
@@ -178,8 +426,52 @@ func main() {
 	stopCh := make(chan struct{})
 	defer close(stopCh)
 
-	// run the controller loop to process items
-	go controller.Run(stopCh)
+	// PopulatorClasses define populator types, every replica keeps track of them since the webhooks validate
+	// against them too.  Wait for them before doing anything else, otherwise a Populator using a class could be
+	// rejected at start up
+	classInformer := newClassInformer(populatorClient)
+	go classInformer.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, classInformer.HasSynced) {
+		log.Fatalf("unable to sync PopulatorClasses")
+	}
+
+	// the webhooks are stateless so every replica serves them, leader or not
+	if webhookEnabled() {
+		server := &webhook.Server{
+			Addr:            webhookAddr,
+			CertFile:        webhookCert,
+			KeyFile:         webhookKey,
+			KubeClient:      k8sClient,
+			PopulatorClient: populatorClient,
+		}
+		go func() {
+			if err := server.Run(stopCh); err != nil {
+				log.Fatalf("admission webhook server failed: %v", err)
+			}
+		}()
+	}
+
+	// run the controller loop to process items, if we're running more than one
+	// replica only the holder of the Lease gets to do any work
+	if leaderElect {
+		go runWithLeaderElection(k8sClient, recorder, func(ctx context.Context) {
+			if evictor != nil {
+				go evictor.Run(ctx.Done())
+			}
+			go scheduler.Run(ctx.Done())
+			go requestController.Run(ctx.Done())
+			go jobController.Run(ctx.Done())
+			controller.Run(ctx.Done())
+		})
+	} else {
+		if evictor != nil {
+			go evictor.Run(stopCh)
+		}
+		go scheduler.Run(stopCh)
+		go requestController.Run(stopCh)
+		go jobController.Run(stopCh)
+		go controller.Run(stopCh)
+	}
 
 	// use a channel to handle OS signals to terminate and gracefully shut
 	// down processing
@@ -188,3 +480,106 @@ func main() {
 	signal.Notify(sigTerm, syscall.SIGINT)
 	<-sigTerm
 }
+
+// int32Flag lets us bind flags straight to the int32 defaults in the populator package
+type int32Flag struct {
+	v *int32
+}
+
+func (f int32Flag) String() string {
+	if f.v == nil {
+		return "0"
+	}
+	return strconv.Itoa(int(*f.v))
+}
+
+func (f int32Flag) Set(s string) error {
+	n, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return err
+	}
+	*f.v = int32(n)
+	return nil
+}
+
+// cidrsFlag binds a comma separated list of CIDRs to the populator package's pre-flight ranges, replacing them or
+// adding to them
+type cidrsFlag struct {
+	v   *[]*net.IPNet
+	add bool
+}
+
+func (f cidrsFlag) String() string {
+	if f.v == nil {
+		return ""
+	}
+	var cidrs []string
+	for _, n := range *f.v {
+		cidrs = append(cidrs, n.String())
+	}
+	return strings.Join(cidrs, ",")
+}
+
+func (f cidrsFlag) Set(s string) error {
+	nets, err := populator.ParseCIDRs(s)
+	if err != nil {
+		return err
+	}
+	if f.add {
+		nets = append(*f.v, nets...)
+	}
+	*f.v = nets
+	return nil
+}
+
+// webhookEnabled checks we've been given a certificate to serve the webhooks with, the deployment mounts the
+// certificate secret as optional so we just carry on without webhooks if it hasn't been created
+func webhookEnabled() bool {
+	if webhookCert == "" || webhookKey == "" {
+		return false
+	}
+	for _, f := range []string{webhookCert, webhookKey} {
+		if _, err := os.Stat(f); err != nil {
+			log.Printf("admission webhooks disabled: %v", err)
+			return false
+		}
+	}
+	return true
+}
+
+// runWithLeaderElection blocks until we acquire the populator-controller Lease and then calls run, if we
+// ever lose the Lease we exit and let the Deployment restart us rather than risk two active controllers
+func runWithLeaderElection(c kubernetes.Interface, recorder record.EventRecorder, run func(ctx context.Context)) {
+	ns := leaderElectionNamespace
+	if ns == "" {
+		ns = os.Getenv("POD_NAMESPACE")
+	}
+	if ns == "" {
+		ns = metav1.NamespaceDefault
+	}
+	id, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("unable to determine leader election identity: %v", err)
+	}
+
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock, ns, componentName,
+		c.CoreV1(), c.CoordinationV1(),
+		resourcelock.ResourceLockConfig{Identity: id, EventRecorder: recorder})
+	if err != nil {
+		log.Fatalf("unable to create leader election lock: %v", err)
+	}
+
+	log.Printf("waiting to acquire lease %s/%s as %s", ns, componentName, id)
+	leaderelection.RunOrDie(context.Background(), leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: 15 * time.Second,
+		RenewDeadline: 10 * time.Second,
+		RetryPeriod:   2 * time.Second,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: run,
+			OnStoppedLeading: func() {
+				log.Fatalf("lost lease %s/%s, exiting", ns, componentName)
+			},
+		},
+	})
+}
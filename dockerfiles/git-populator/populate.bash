@@ -1,6 +1,114 @@
 #!/usr/bin/bash
+set -euo pipefail
 
 REPO=$1
 BRANCH=$2
 DEST=$3
-git clone -b "$BRANCH"  "$REPO" "$DEST"
+
+# The controller validates these, but they go straight on git's command line so make sure neither can be taken
+# for an option.  Positional arguments below also follow a "--" for the same reason
+for ARG in "$REPO" "$BRANCH"; do
+  case "$ARG" in
+    -*) echo "refusing to use $ARG, it starts with -" >&2; exit 1 ;;
+  esac
+done
+
+# Credentials come from the Populator's secret_ref, the controller mounts the keys we use as files in
+# $POPULATOR_SECRET_DIR (see pkg/populator/secret.go) so they never show up in our args or the job spec.
+# username/password are for http(s) repos, ssh-privatekey and known_hosts for ssh ones
+if [ -n "${POPULATOR_SECRET_DIR:-}" ]; then
+  SCRATCH=$(mktemp -d)
+  trap 'rm -rf "$SCRATCH"' EXIT
+  if [ -f "$POPULATOR_SECRET_DIR/password" ]; then
+    cat > "$SCRATCH/askpass" <<'EOF'
+#!/usr/bin/bash
+case "$1" in
+  Username*) cat "$POPULATOR_SECRET_DIR/username" 2>/dev/null || echo git ;;
+  *) cat "$POPULATOR_SECRET_DIR/password" ;;
+esac
+EOF
+    chmod 700 "$SCRATCH/askpass"
+    export GIT_ASKPASS="$SCRATCH/askpass" GIT_TERMINAL_PROMPT=0
+  fi
+  if [ -f "$POPULATOR_SECRET_DIR/ssh-privatekey" ]; then
+    # ssh won't use a key anybody else can read, the mounted one is readable by the pod's group
+    cp "$POPULATOR_SECRET_DIR/ssh-privatekey" "$SCRATCH/id"
+    chmod 600 "$SCRATCH/id"
+    GIT_SSH_COMMAND="ssh -i $SCRATCH/id -o IdentitiesOnly=yes"
+    if [ -f "$POPULATOR_SECRET_DIR/known_hosts" ]; then
+      GIT_SSH_COMMAND+=" -o UserKnownHostsFile=$POPULATOR_SECRET_DIR/known_hosts -o StrictHostKeyChecking=yes"
+    else
+      GIT_SSH_COMMAND+=" -o StrictHostKeyChecking=accept-new"
+    fi
+    export GIT_SSH_COMMAND
+  fi
+fi
+
+# Where we fetch from, the repo itself or our mirror of it in the shared download cache
+SOURCE="$REPO"
+if [ -n "${POPULATOR_CACHE_DIR:-}" ]; then
+  # Keep a bare mirror of the repo in the shared download cache (see pkg/populator/downloadcache.go), only what's
+  # changed since the last population comes over the network and the clone itself is local.  The mirror is keyed
+  # by the scope (namespace and secret) too, so only jobs using the same credentials share it.  The lock keeps
+  # other jobs and cache eviction off the mirror while we use it
+  KEY=$(printf '%s\n%s' "${POPULATOR_CACHE_SCOPE:-}" "$REPO" | sha256sum | cut -d' ' -f1)
+  MIRROR="$POPULATOR_CACHE_DIR/git/$KEY.git"
+  mkdir -p "$POPULATOR_CACHE_DIR/git" "$POPULATOR_CACHE_DIR/locks"
+  exec 9>"$POPULATOR_CACHE_DIR/locks/$KEY.lock"
+  flock 9
+  if [ -d "$MIRROR" ]; then
+    git --git-dir "$MIRROR" fetch --prune -- origin
+  else
+    rm -rf "$MIRROR.tmp"
+    git clone --mirror -- "$REPO" "$MIRROR.tmp"
+    mv "$MIRROR.tmp" "$MIRROR"
+  fi
+  touch "$MIRROR"
+  SOURCE="$MIRROR"
+fi
+
+if [ "${POPULATOR_REFRESH:-}" = "true" ] && [ -d "$DEST/.git" ]; then
+  # Bring the earlier clone up to date in place, changes to tracked files are thrown away.  Untracked files are
+  # left alone, they may well be other sources populated into subpaths of this one
+  git -C "$DEST" fetch --force --tags -- "$SOURCE" "$BRANCH"
+  if git -C "$DEST" show-ref --verify --quiet "refs/tags/$BRANCH"; then
+    git -C "$DEST" checkout --force --detach FETCH_HEAD --
+  else
+    git -C "$DEST" checkout --force -B "$BRANCH" FETCH_HEAD --
+  fi
+else
+  if [ "${POPULATOR_REFRESH:-}" = "true" ] && [ -d "$DEST" ]; then
+    # a refresh of something that isn't a clone (an earlier population that failed part way), start again
+    find "$DEST" -mindepth 1 -maxdepth 1 ! -name .populator -exec rm -rf {} +
+  fi
+  git clone --no-hardlinks -b "$BRANCH" -- "$SOURCE" "$DEST"
+fi
+if [ -n "${POPULATOR_CACHE_DIR:-}" ]; then
+  flock -u 9
+fi
+git -C "$DEST" remote set-url -- origin "$REPO"
+
+# Record exactly what we wrote (see pkg/populator/manifest.go for the format) and hand a summary back to the
+# controller through the termination message
+if [ -n "${POPULATOR_MANIFEST_DIR:-}" ]; then
+  REVISION=$(git -C "$DEST" rev-parse HEAD)
+  STEP=${POPULATOR_STEP:-git}
+  MANIFEST="$POPULATOR_MANIFEST_DIR/$STEP.manifest"
+  mkdir -p "$POPULATOR_MANIFEST_DIR"
+  {
+    # the controller won't accept credentials in the URL, but older Populators may still have them
+    echo "# source: $(printf '%s' "$REPO" | sed -E 's#^(https?://)[^/@]*@#\1#')"
+    echo "# revision: $REVISION"
+    cd "$DEST"
+    find . \( -path ./.git -o -path ./.populator \) -prune -o -type f -print0 | sort -z |
+      while IFS= read -r -d '' f; do
+        printf '%s %s %s\n' "$(sha256sum "$f" | cut -d' ' -f1)" "$(stat -c %s "$f")" "${f#./}"
+      done
+  } > "$MANIFEST"
+
+  FILES=$(grep -vc '^#' "$MANIFEST" || true)
+  BYTES=$(awk '!/^#/ { total += $2 } END { print total + 0 }' "$MANIFEST")
+  DIGEST=$(sha256sum "$MANIFEST" | cut -d' ' -f1)
+  printf '{"step":"%s","revision":"%s","manifestDigest":"sha256:%s","files":%d,"bytes":%d}' \
+    "$STEP" "$REVISION" "$DIGEST" "$FILES" "$BYTES" > "${POPULATOR_TERMINATION_LOG:-/dev/termination-log}"
+fi
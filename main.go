@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -44,12 +45,12 @@ func main() {
 	}
 
 	/*
-		projects, err := clientSet.Populators("default").List(metav1.ListOptions{})
+		projects, err := clientSet.Populators("default").List(context.TODO(), metav1.ListOptions{})
 		if err != nil {
 			panic(err)
 		}
 	*/
-	p, err := clientSet.Populators("default").Get("my-populator", metav1.GetOptions{})
+	p, err := clientSet.Populators("default").Get(context.TODO(), "my-populator", metav1.GetOptions{})
 	if err != nil {
 		panic(err)
 	}
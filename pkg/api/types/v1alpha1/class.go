@@ -0,0 +1,70 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClassKind is the kind of the cluster scoped PopulatorClass
+const ClassKind = "PopulatorClass"
+
+// PopulatorClass lets cluster admins add a populator type without rebuilding the controller.  The class name is
+// the type name Populators use, a class never replaces a type that's built in (or registered in the controller)
+type PopulatorClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PopulatorClassSpec `json:"spec"`
+}
+
+// PopulatorClassSpec describes the container that populates a source of the class's type.  Args and env values
+// are Go templates rendered with .Parameters (the source's parameters, with defaults applied), .Dest (the
+// directory to populate), .Subpath, .CacheDir (the shared download cache, empty if there isn't one) and .Refresh
+// (true when an already populated volume is being brought up to date) and .SecretDir (where SecretFiles are)
+type PopulatorClassSpec struct {
+	Image       string            `json:"image"`
+	Command     []string          `json:"command,omitempty"` // Defaults to the image's entrypoint
+	Args        []string          `json:"args,omitempty"`
+	Env         []ClassEnvVar     `json:"env,omitempty"`
+	SecretEnv   []ClassSecretEnv  `json:"secret_env,omitempty"`   // Keys of the Populator's secret_ref passed as env
+	SecretFiles []ClassSecretFile `json:"secret_files,omitempty"` // Keys of the Populator's secret_ref passed as files
+	Parameters  []ClassParameter  `json:"parameters,omitempty"`
+}
+
+// ClassEnvVar is an environment variable for the populator container, Value is a template
+type ClassEnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ClassSecretEnv maps a key of the Populator's secret to an environment variable.  The value is never rendered
+// into args or the job spec, the container reads it from the secret itself
+type ClassSecretEnv struct {
+	Name     string `json:"name"`
+	Key      string `json:"key"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// ClassSecretFile projects a key of the Populator's secret into the container as a file, Path is relative to
+// .SecretDir.  Only the keys the class lists are mounted, readable by the pod's fsGroup
+type ClassSecretFile struct {
+	Key      string `json:"key"`
+	Path     string `json:"path"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// ClassParameter declares a parameter Populators of the class may set, when a class declares any parameters
+// those are the only ones allowed
+type ClassParameter struct {
+	Name        string `json:"name"`
+	Required    bool   `json:"required,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// PopulatorClassList provides a type of multiple PopulatorClasses
+type PopulatorClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []PopulatorClass `json:"items"`
+}
@@ -0,0 +1,63 @@
+package v1alpha1
+
+import (
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterKind is the kind a PVC dataSource uses to reference a ClusterPopulator
+const ClusterKind = "ClusterPopulator"
+
+// ClusterPopulator is a cluster scoped Populator any namespace can use, e.g. for curated base data.  Its spec is
+// the same as a Populator's except that Include names other ClusterPopulators and SecretRef names a secret in the
+// controller's namespace, which is copied into the PVC's namespace for each job that needs it
+type ClusterPopulator struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PopulatorSpec   `json:"spec"`
+	Status PopulatorStatus `json:"status,omitempty"`
+}
+
+// ClusterPopulatorList provides a type of multiple ClusterPopulators
+type ClusterPopulatorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ClusterPopulator `json:"items"`
+}
+
+// IsClusterPopulatorDataSource returns true if the PVC dataSource reference points at a ClusterPopulator rather than
+// a Populator, IsPopulatorDataSource is true for both
+func IsClusterPopulatorDataSource(ds *core_v1.TypedLocalObjectReference) bool {
+	if ds == nil || ds.APIGroup == nil {
+		return false
+	}
+	return *ds.APIGroup == GroupName && ds.Kind == ClusterKind
+}
+
+// AsPopulator returns a copy of the ClusterPopulator as a Populator with no namespace, so everything that works
+// with Populators works with it too.  IsCluster tells them apart again
+func (c *ClusterPopulator) AsPopulator() *Populator {
+	p := &Populator{}
+	p.TypeMeta = metav1.TypeMeta{APIVersion: SchemeGroupVersion.String(), Kind: ClusterKind}
+	c.ObjectMeta.DeepCopyInto(&p.ObjectMeta)
+	c.Spec.DeepCopyInto(&p.Spec)
+	c.Status.DeepCopyInto(&p.Status)
+	return p
+}
+
+// AsClusterPopulator turns a Populator from AsPopulator back into the ClusterPopulator
+func (p *Populator) AsClusterPopulator() *ClusterPopulator {
+	c := &ClusterPopulator{}
+	c.TypeMeta = metav1.TypeMeta{APIVersion: SchemeGroupVersion.String(), Kind: ClusterKind}
+	p.ObjectMeta.DeepCopyInto(&c.ObjectMeta)
+	p.Spec.DeepCopyInto(&c.Spec)
+	p.Status.DeepCopyInto(&c.Status)
+	return c
+}
+
+// IsCluster returns true if the Populator is really a ClusterPopulator (see AsPopulator)
+func (p *Populator) IsCluster() bool {
+	return p.Kind == ClusterKind
+}
@@ -1,15 +1,180 @@
 package v1alpha1
 
-import "k8s.io/apimachinery/pkg/runtime"
+import (
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
 
 // DeepCopyInto copies all properties of this object into another object of the
 // same type that is provided as a pointer.
 func (in *Populator) DeepCopyInto(out *Populator) {
 	out.TypeMeta = in.TypeMeta
-	out.ObjectMeta = in.ObjectMeta
-	out.Spec = PopulatorSpec{
-		Type: in.Spec.Type,
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopyInto copies the status and its conditions into out
+func (in *PopulatorStatus) DeepCopyInto(out *PopulatorStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(in.Conditions))
+		for i := range in.Conditions {
+			in.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+// DeepCopyInto copies the spec, including any optional source blocks, into out
+func (in *PopulatorSpec) DeepCopyInto(out *PopulatorSpec) {
+	*out = *in
+	if in.S3 != nil {
+		out.S3 = new(S3Populator)
+		*out.S3 = *in.S3
+	}
+	out.Parameters = copyParameters(in.Parameters)
+	if in.Sources != nil {
+		out.Sources = make([]Source, len(in.Sources))
+		for i := range in.Sources {
+			in.Sources[i].DeepCopyInto(&out.Sources[i])
+		}
+	}
+	if in.Include != nil {
+		out.Include = make([]string, len(in.Include))
+		copy(out.Include, in.Include)
+	}
+	if in.Overridable != nil {
+		out.Overridable = make([]string, len(in.Overridable))
+		copy(out.Overridable, in.Overridable)
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		out.TTLSecondsAfterFinished = new(int32)
+		*out.TTLSecondsAfterFinished = *in.TTLSecondsAfterFinished
+	}
+	if in.BackoffLimit != nil {
+		out.BackoffLimit = new(int32)
+		*out.BackoffLimit = *in.BackoffLimit
+	}
+	if in.ActiveDeadlineSeconds != nil {
+		out.ActiveDeadlineSeconds = new(int64)
+		*out.ActiveDeadlineSeconds = *in.ActiveDeadlineSeconds
+	}
+	if in.JobTemplate != nil {
+		out.JobTemplate = in.JobTemplate.DeepCopy()
+	}
+	out.Ownership = in.Ownership.DeepCopy()
+	out.PostPopulate = in.PostPopulate.DeepCopy()
+	if in.Cache != nil {
+		out.Cache = new(Cache)
+		*out.Cache = *in.Cache
+	}
+}
+
+// DeepCopy returns a new copy of the Hook
+func (in *Hook) DeepCopy() *Hook {
+	if in == nil {
+		return nil
+	}
+	out := new(Hook)
+	*out = *in
+	if in.Command != nil {
+		out.Command = append([]string{}, in.Command...)
+	}
+	if in.Args != nil {
+		out.Args = append([]string{}, in.Args...)
+	}
+	return out
+}
+
+// DeepCopyInto copies the source, including its source block, into out
+func (in *Source) DeepCopyInto(out *Source) {
+	*out = *in
+	if in.Git != nil {
+		out.Git = new(GitPopulator)
+		*out.Git = *in.Git
+	}
+	if in.S3 != nil {
+		out.S3 = new(S3Populator)
+		*out.S3 = *in.S3
+	}
+	out.Parameters = copyParameters(in.Parameters)
+}
+
+// copyParameters returns a copy of a source's Parameters, nil stays nil
+func copyParameters(in map[string]string) map[string]string {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+// DeepCopy returns a new copy of the Ownership
+func (in *Ownership) DeepCopy() *Ownership {
+	if in == nil {
+		return nil
+	}
+	out := new(Ownership)
+	*out = *in
+	if in.UID != nil {
+		out.UID = new(int64)
+		*out.UID = *in.UID
+	}
+	if in.GID != nil {
+		out.GID = new(int64)
+		*out.GID = *in.GID
+	}
+	return out
+}
+
+// DeepCopy returns a new copy of the JobTemplate
+func (in *JobTemplate) DeepCopy() *JobTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(JobTemplate)
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.NodeSelector != nil {
+		out.NodeSelector = make(map[string]string, len(in.NodeSelector))
+		for k, v := range in.NodeSelector {
+			out.NodeSelector[k] = v
+		}
 	}
+	out.Affinity = in.Affinity.DeepCopy()
+	if in.Tolerations != nil {
+		out.Tolerations = make([]core_v1.Toleration, len(in.Tolerations))
+		for i := range in.Tolerations {
+			in.Tolerations[i].DeepCopyInto(&out.Tolerations[i])
+		}
+	}
+	if in.RunAsUser != nil {
+		out.RunAsUser = new(int64)
+		*out.RunAsUser = *in.RunAsUser
+	}
+	if in.FSGroup != nil {
+		out.FSGroup = new(int64)
+		*out.FSGroup = *in.FSGroup
+	}
+	if in.ImagePullSecrets != nil {
+		out.ImagePullSecrets = make([]core_v1.LocalObjectReference, len(in.ImagePullSecrets))
+		copy(out.ImagePullSecrets, in.ImagePullSecrets)
+	}
+	return out
+}
+
+// DeepCopy returns a new copy of the Populator
+func (in *Populator) DeepCopy() *Populator {
+	if in == nil {
+		return nil
+	}
+	out := new(Populator)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyObject returns a generically typed copy of an object
@@ -35,3 +200,214 @@ func (in *PopulatorList) DeepCopyObject() runtime.Object {
 
 	return &out
 }
+
+// DeepCopyInto copies the class and its spec into out
+func (in *PopulatorClass) DeepCopyInto(out *PopulatorClass) {
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	if in.Spec.Command != nil {
+		out.Spec.Command = append([]string{}, in.Spec.Command...)
+	}
+	if in.Spec.Args != nil {
+		out.Spec.Args = append([]string{}, in.Spec.Args...)
+	}
+	if in.Spec.Env != nil {
+		out.Spec.Env = append([]ClassEnvVar{}, in.Spec.Env...)
+	}
+	if in.Spec.SecretEnv != nil {
+		out.Spec.SecretEnv = append([]ClassSecretEnv{}, in.Spec.SecretEnv...)
+	}
+	if in.Spec.SecretFiles != nil {
+		out.Spec.SecretFiles = append([]ClassSecretFile{}, in.Spec.SecretFiles...)
+	}
+	if in.Spec.Parameters != nil {
+		out.Spec.Parameters = append([]ClassParameter{}, in.Spec.Parameters...)
+	}
+}
+
+// DeepCopy returns a new copy of the PopulatorClass
+func (in *PopulatorClass) DeepCopy() *PopulatorClass {
+	if in == nil {
+		return nil
+	}
+	out := new(PopulatorClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject returns a generically typed copy of an object
+func (in *PopulatorClass) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+// DeepCopyObject returns a generically typed copy of an object
+func (in *PopulatorClassList) DeepCopyObject() runtime.Object {
+	out := PopulatorClassList{}
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+
+	if in.Items != nil {
+		out.Items = make([]PopulatorClass, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+
+	return &out
+}
+
+// DeepCopy returns a new copy of the Overrides
+func (in *Overrides) DeepCopy() *Overrides {
+	if in == nil {
+		return nil
+	}
+	out := new(Overrides)
+	*out = *in
+	out.Parameters = copyParameters(in.Parameters)
+	return out
+}
+
+// DeepCopyInto copies all properties of this object into another object of the same type that is provided as a pointer
+func (in *PopulationRequest) DeepCopyInto(out *PopulationRequest) {
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	out.Spec.Overrides = in.Spec.Overrides.DeepCopy()
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopyInto copies all properties of this object into another object of the same type that is provided as a pointer
+func (in *PopulationRequestStatus) DeepCopyInto(out *PopulationRequestStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		out.StartTime = in.StartTime.DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		out.CompletionTime = in.CompletionTime.DeepCopy()
+	}
+}
+
+// DeepCopy returns a new copy of the PopulationRequestStatus
+func (in *PopulationRequestStatus) DeepCopy() *PopulationRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PopulationRequestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopy returns a new copy of the PopulationRequest
+func (in *PopulationRequest) DeepCopy() *PopulationRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(PopulationRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject returns a generically typed copy of an object
+func (in *PopulationRequest) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+// DeepCopyObject returns a generically typed copy of an object
+func (in *PopulationRequestList) DeepCopyObject() runtime.Object {
+	out := PopulationRequestList{}
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+
+	if in.Items != nil {
+		out.Items = make([]PopulationRequest, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+
+	return &out
+}
+
+// DeepCopyInto copies all properties of this object into another object of the same type that is provided as a pointer
+func (in *PopulatorGrant) DeepCopyInto(out *PopulatorGrant) {
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	if in.Spec.From != nil {
+		out.Spec.From = append([]GrantFrom{}, in.Spec.From...)
+	}
+	if in.Spec.To != nil {
+		out.Spec.To = append([]GrantTo{}, in.Spec.To...)
+	}
+}
+
+// DeepCopy returns a new copy of the PopulatorGrant
+func (in *PopulatorGrant) DeepCopy() *PopulatorGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(PopulatorGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject returns a generically typed copy of an object
+func (in *PopulatorGrant) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+// DeepCopyObject returns a generically typed copy of an object
+func (in *PopulatorGrantList) DeepCopyObject() runtime.Object {
+	out := PopulatorGrantList{}
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+
+	if in.Items != nil {
+		out.Items = make([]PopulatorGrant, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+
+	return &out
+}
+
+// DeepCopyInto copies all properties of this object into another object of the same type that is provided as a pointer
+func (in *ClusterPopulator) DeepCopyInto(out *ClusterPopulator) {
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy returns a new copy of the ClusterPopulator
+func (in *ClusterPopulator) DeepCopy() *ClusterPopulator {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterPopulator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject returns a generically typed copy of an object
+func (in *ClusterPopulator) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+// DeepCopyObject returns a generically typed copy of an object
+func (in *ClusterPopulatorList) DeepCopyObject() runtime.Object {
+	out := ClusterPopulatorList{}
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+
+	if in.Items != nil {
+		out.Items = make([]ClusterPopulator, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+
+	return &out
+}
@@ -0,0 +1,69 @@
+package v1alpha1
+
+import "path"
+
+// Defaults for fields the user doesn't have to fill in
+const (
+	DefaultGitImage   = "jgriffith/git-populator"
+	DefaultGitBranch  = "master"
+	DefaultMountpoint = "/data"
+)
+
+// Built in defaults for the job lifecycle fields.  These aren't written into the Populator by Default, they're
+// the starting values of the controller wide defaults which the manager lets you change with flags
+const (
+	DefaultTTLSecondsAfterFinished = int32(30)
+	DefaultBackoffLimit            = int32(3)
+	DefaultActiveDeadlineSeconds   = int64(3600)
+)
+
+// DefaultImages maps a populator type to the image we run for it when the Populator doesn't specify one
+var DefaultImages = map[string]string{
+	TypeGit: DefaultGitImage,
+}
+
+// Default fills in any unset fields of the Populator and normalizes the mountpoint.  It's applied by the
+// mutating webhook and again by the controller before building a job, so it has to be safe to call twice
+func (p *Populator) Default() {
+	p.Spec.Default()
+}
+
+// Default fills in any unset fields of the spec
+func (s *PopulatorSpec) Default() {
+	s.Mountpoint = NormalizeMountpoint(s.Mountpoint)
+	if s.Type != "" {
+		if s.Image == "" {
+			s.Image = DefaultImages[s.Type]
+		}
+		if s.Type == TypeGit && s.Git.Branch == "" && s.Git.Tag == "" {
+			s.Git.Branch = DefaultGitBranch
+		}
+	}
+	for i := range s.Sources {
+		s.Sources[i].Default()
+	}
+}
+
+// Default fills in any unset fields of the source
+func (s *Source) Default() {
+	if s.Image == "" {
+		s.Image = DefaultImages[s.Type]
+	}
+	if s.Git != nil && s.Git.Branch == "" && s.Git.Tag == "" {
+		s.Git.Branch = DefaultGitBranch
+	}
+}
+
+// SourcePath returns the directory a source with the given subpath is written to
+func SourcePath(mountpoint, subpath string) string {
+	return path.Join(NormalizeMountpoint(mountpoint), subpath)
+}
+
+// NormalizeMountpoint turns mountpoints like "git", "/git/" or "//git" into "/git", an empty mountpoint gets
+// DefaultMountpoint
+func NormalizeMountpoint(mountpoint string) string {
+	if mountpoint == "" {
+		return DefaultMountpoint
+	}
+	return path.Clean("/" + mountpoint)
+}
@@ -0,0 +1,67 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AnnPopulatorNamespace on a PVC says which namespace the Populator its dataSource names is in, it defaults to
+// the PVC's own.  A Populator in another namespace can only be used if a PopulatorGrant there allows it
+const AnnPopulatorNamespace = "populator.k8s.io/populator-namespace"
+
+// PopulatorGrant lets PVCs in other namespaces use Populators in the grant's namespace, in the same way a
+// Gateway API ReferenceGrant does for routes.  Populators shared like this can't have a SecretRef, secrets are
+// never used outside their own namespace
+type PopulatorGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PopulatorGrantSpec `json:"spec"`
+}
+
+// PopulatorGrantSpec lists the namespaces allowed to use the Populators named in To, an empty To grants every
+// Populator in the namespace
+type PopulatorGrantSpec struct {
+	From []GrantFrom `json:"from"`
+	To   []GrantTo   `json:"to,omitempty"`
+}
+
+// GrantFrom is a namespace PVCs may use the granted Populators from, "*" is any namespace
+type GrantFrom struct {
+	Namespace string `json:"namespace"`
+}
+
+// GrantTo names a Populator the grant applies to
+type GrantTo struct {
+	Name string `json:"name"`
+}
+
+// PopulatorGrantList provides a type of multiple PopulatorGrants
+type PopulatorGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []PopulatorGrant `json:"items"`
+}
+
+// Allows returns true if the grant lets PVCs in namespace use the named Populator
+func (g *PopulatorGrant) Allows(namespace, populator string) bool {
+	from := false
+	for _, f := range g.Spec.From {
+		if f.Namespace == namespace || f.Namespace == "*" {
+			from = true
+			break
+		}
+	}
+	if !from {
+		return false
+	}
+	if len(g.Spec.To) == 0 {
+		return true
+	}
+	for _, t := range g.Spec.To {
+		if t.Name == populator {
+			return true
+		}
+	}
+	return false
+}
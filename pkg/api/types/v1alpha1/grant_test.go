@@ -0,0 +1,66 @@
+package v1alpha1
+
+import "testing"
+
+func TestPopulatorGrantAllows(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      PopulatorGrantSpec
+		namespace string
+		populator string
+		allowed   bool
+	}{
+		{
+			name:      "namespace and populator",
+			spec:      PopulatorGrantSpec{From: []GrantFrom{{Namespace: "team-a"}}, To: []GrantTo{{Name: "base"}}},
+			namespace: "team-a",
+			populator: "base",
+			allowed:   true,
+		},
+		{
+			name:      "every populator",
+			spec:      PopulatorGrantSpec{From: []GrantFrom{{Namespace: "team-a"}}},
+			namespace: "team-a",
+			populator: "anything",
+			allowed:   true,
+		},
+		{
+			name:      "any namespace",
+			spec:      PopulatorGrantSpec{From: []GrantFrom{{Namespace: "*"}}, To: []GrantTo{{Name: "base"}}},
+			namespace: "team-b",
+			populator: "base",
+			allowed:   true,
+		},
+		{
+			name:      "one of several namespaces",
+			spec:      PopulatorGrantSpec{From: []GrantFrom{{Namespace: "team-a"}, {Namespace: "team-b"}}},
+			namespace: "team-b",
+			populator: "base",
+			allowed:   true,
+		},
+		{
+			name:      "other namespace",
+			spec:      PopulatorGrantSpec{From: []GrantFrom{{Namespace: "team-a"}}},
+			namespace: "team-b",
+			populator: "base",
+		},
+		{
+			name:      "other populator",
+			spec:      PopulatorGrantSpec{From: []GrantFrom{{Namespace: "team-a"}}, To: []GrantTo{{Name: "base"}}},
+			namespace: "team-a",
+			populator: "secret-data",
+		},
+		{
+			name:      "no namespaces",
+			spec:      PopulatorGrantSpec{To: []GrantTo{{Name: "base"}}},
+			namespace: "team-a",
+			populator: "base",
+		},
+	}
+	for _, tt := range tests {
+		g := &PopulatorGrant{Spec: tt.spec}
+		if got := g.Allows(tt.namespace, tt.populator); got != tt.allowed {
+			t.Errorf("%s: Allows(%q, %q) = %v, want %v", tt.name, tt.namespace, tt.populator, got, tt.allowed)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package v1alpha1
+
+// Labels the controller puts on populator jobs so we can find our way back to the PVC and Populator
+const (
+	LabelPVC       = "populator.k8s.io/pvc"
+	LabelPopulator = "populator.k8s.io/populator"
+	// LabelRequest marks jobs run for a PopulationRequest, the value is the request's name
+	LabelRequest = "populator.k8s.io/request"
+	// LabelGolden marks the golden PVC of a cached Populator (the value is the Populator's name), LabelWaitingFor
+	// marks a PVC waiting for a golden PVC to be populated and LabelPrimeFor marks the PVC a golden volume is
+	// being cloned into on behalf of another PVC (the values are PVC names)
+	LabelGolden     = "populator.k8s.io/golden"
+	LabelWaitingFor = "populator.k8s.io/waiting-for"
+	LabelPrimeFor   = "populator.k8s.io/prime-for"
+)
+
+// Annotations the controller records population progress in on the PVC
+const (
+	AnnPopulationStatus  = "populator.k8s.io/status"
+	AnnPopulationJob     = "populator.k8s.io/job"
+	AnnPopulationMessage = "populator.k8s.io/message"
+	// AnnContentDigest is a digest over every source's manifest, AnnManifest lists each source's revision and
+	// manifest digest (the manifests themselves are in .populator/ on the volume)
+	AnnContentDigest = "populator.k8s.io/content-digest"
+	AnnManifest      = "populator.k8s.io/manifest"
+	// AnnPopulatedFrom names the golden PVC a cached PVC was copied from
+	AnnPopulatedFrom = "populator.k8s.io/populated-from"
+	// AnnRefresh is set by users to have an already populated PVC brought up to date, any new value triggers a
+	// refresh.  AnnRefreshed is the last value the controller acted on
+	AnnRefresh   = "populator.k8s.io/refresh"
+	AnnRefreshed = "populator.k8s.io/refreshed"
+	// AnnRefreshSchedule sets a cron schedule to refresh the PVC on, overriding its Populator's RefreshSchedule.
+	// AnnLastScheduledRefresh is when the controller last requested a scheduled refresh (RFC 3339)
+	AnnRefreshSchedule      = "populator.k8s.io/refresh-schedule"
+	AnnLastScheduledRefresh = "populator.k8s.io/last-scheduled-refresh"
+)
+
+// ConditionSourceReachable is the Populator condition recording whether the pre-flight check could reach every
+// one of its sources, the reason is one of the SourceReachable/SourceUnreachable values below
+const (
+	ConditionSourceReachable = "SourceReachable"
+	ReasonSourceReachable    = "Reachable"
+	ReasonSourceUnreachable  = "Unreachable"
+)
+
+// Values of the AnnPopulationStatus annotation
+const (
+	PopulationPending   = "Pending" // waiting for a golden volume to be populated
+	PopulationRunning   = "Running"
+	PopulationSucceeded = "Succeeded"
+	PopulationFailed    = "Failed"
+)
@@ -0,0 +1,173 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Annotations a PVC can set to override its Populator's sources, the Populator has to allow each of them in
+// Overridable.  A parameter is overridden with AnnOverrideParameterPrefix followed by its name
+const (
+	AnnOverrideBranch          = "populator.k8s.io/git-branch"
+	AnnOverrideTag             = "populator.k8s.io/git-tag"
+	AnnOverrideSubpath         = "populator.k8s.io/subpath"
+	AnnOverrideParameterPrefix = "populator.k8s.io/parameter."
+)
+
+// Values of PopulatorSpec.Overridable, a parameter is allowed with OverrideParameterPrefix followed by its name
+const (
+	OverrideBranch          = "branch"
+	OverrideTag             = "tag"
+	OverrideSubpath         = "subpath"
+	OverrideParameterPrefix = "parameters."
+)
+
+// overridableRegexp matches the values we accept in PopulatorSpec.Overridable
+var overridableRegexp = regexp.MustCompile(`^(branch|tag|subpath|parameters\..+)$`)
+
+// Overrides change a Populator's sources for a single population without touching the Populator itself.  Branch
+// and Tag apply to git sources (setting one clears the other), Parameters are merged into the parameters of every
+// source of a registered type and Subpath replaces the source's subpath, which only makes sense for a Populator
+// with a single source
+type Overrides struct {
+	Branch     string            `json:"branch,omitempty"`
+	Tag        string            `json:"tag,omitempty"`
+	Subpath    string            `json:"subpath,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// Validate checks the overrides on their own, whether they suit a particular Populator is up to Apply
+func (o *Overrides) Validate(fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if o.Branch != "" && o.Tag != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("tag"), "only one of branch and tag may be set"))
+	}
+	if o.Branch != "" {
+		allErrs = append(allErrs, ValidateGitRef(o.Branch, fldPath.Child("branch"))...)
+	}
+	if o.Tag != "" {
+		allErrs = append(allErrs, ValidateGitRef(o.Tag, fldPath.Child("tag"))...)
+	}
+	if o.Subpath != "" && (path.IsAbs(o.Subpath) || strings.HasPrefix(path.Clean(o.Subpath), "..")) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("subpath"), o.Subpath, "must be a relative path inside the mountpoint"))
+	}
+	return allErrs
+}
+
+// Apply writes the overrides into the Populator's spec.  The spec is rewritten in its sources form so the single
+// source fields don't need to be handled separately, the result populates the same way
+func (o *Overrides) Apply(spec *PopulatorSpec) error {
+	if errs := o.Validate(field.NewPath("overrides")); len(errs) > 0 {
+		return errs.ToAggregate()
+	}
+	sources := make([]Source, 0, len(spec.AllSources()))
+	for _, src := range spec.AllSources() {
+		var out Source
+		src.DeepCopyInto(&out)
+		sources = append(sources, out)
+	}
+	if o.Subpath != "" && len(sources) != 1 {
+		return fmt.Errorf("subpath can only be overridden for a Populator with a single source, it has %d", len(sources))
+	}
+	if (o.Branch != "" || o.Tag != "") && !hasSourceType(sources, TypeGit) {
+		return fmt.Errorf("branch and tag can only be overridden for a Populator with a git source")
+	}
+	for i := range sources {
+		src := &sources[i]
+		if o.Subpath != "" {
+			src.Subpath = o.Subpath
+		}
+		if src.Git != nil && o.Branch != "" {
+			src.Git.Branch, src.Git.Tag = o.Branch, ""
+		}
+		if src.Git != nil && o.Tag != "" {
+			src.Git.Tag = o.Tag
+		}
+		if len(o.Parameters) > 0 && src.Git == nil && src.S3 == nil {
+			params := copyParameters(src.Parameters)
+			if params == nil {
+				params = map[string]string{}
+			}
+			for k, v := range o.Parameters {
+				params[k] = v
+			}
+			src.Parameters = params
+		}
+	}
+	spec.Type = ""
+	spec.Sources = sources
+	return nil
+}
+
+func hasSourceType(sources []Source, sourceType string) bool {
+	for _, src := range sources {
+		if src.Type == sourceType {
+			return true
+		}
+	}
+	return false
+}
+
+// OverridesFromAnnotations returns the overrides a PVC asks for in its annotations, or nil if it doesn't ask for
+// any.  Annotations with empty values are ignored
+func OverridesFromAnnotations(annotations map[string]string) *Overrides {
+	o := &Overrides{
+		Branch:  annotations[AnnOverrideBranch],
+		Tag:     annotations[AnnOverrideTag],
+		Subpath: annotations[AnnOverrideSubpath],
+	}
+	for k, v := range annotations {
+		if name := strings.TrimPrefix(k, AnnOverrideParameterPrefix); name != k && name != "" && v != "" {
+			if o.Parameters == nil {
+				o.Parameters = map[string]string{}
+			}
+			o.Parameters[name] = v
+		}
+	}
+	if o.Branch == "" && o.Tag == "" && o.Subpath == "" && o.Parameters == nil {
+		return nil
+	}
+	return o
+}
+
+// Fields returns the Overridable names of the fields the overrides set, sorted
+func (o *Overrides) Fields() []string {
+	var fields []string
+	if o.Branch != "" {
+		fields = append(fields, OverrideBranch)
+	}
+	if o.Tag != "" {
+		fields = append(fields, OverrideTag)
+	}
+	if o.Subpath != "" {
+		fields = append(fields, OverrideSubpath)
+	}
+	for name := range o.Parameters {
+		fields = append(fields, OverrideParameterPrefix+name)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// CheckOverridable returns an error naming any of the overrides the Populator doesn't allow
+func (s *PopulatorSpec) CheckOverridable(o *Overrides) error {
+	allowed := map[string]bool{}
+	for _, f := range s.Overridable {
+		allowed[f] = true
+	}
+	var denied []string
+	for _, f := range o.Fields() {
+		if !allowed[f] {
+			denied = append(denied, f)
+		}
+	}
+	if len(denied) > 0 {
+		return fmt.Errorf("the Populator doesn't allow %s to be overridden", strings.Join(denied, ", "))
+	}
+	return nil
+}
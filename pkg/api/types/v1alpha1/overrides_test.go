@@ -0,0 +1,161 @@
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOverridesFromAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        *Overrides
+	}{
+		{
+			name: "none",
+		},
+		{
+			name:        "unrelated annotations",
+			annotations: map[string]string{"example.com/owner": "team-a"},
+		},
+		{
+			name:        "empty values are ignored",
+			annotations: map[string]string{AnnOverrideBranch: "", AnnOverrideParameterPrefix + "url": ""},
+		},
+		{
+			name:        "branch",
+			annotations: map[string]string{AnnOverrideBranch: "develop"},
+			want:        &Overrides{Branch: "develop"},
+		},
+		{
+			name: "tag, subpath and parameters",
+			annotations: map[string]string{
+				AnnOverrideTag:                     "v1.0",
+				AnnOverrideSubpath:                 "src",
+				AnnOverrideParameterPrefix + "url": "https://example.com/data.tar",
+				AnnOverrideParameterPrefix:         "no name",
+			},
+			want: &Overrides{Tag: "v1.0", Subpath: "src", Parameters: map[string]string{"url": "https://example.com/data.tar"}},
+		},
+	}
+	for _, tt := range tests {
+		if got := OverridesFromAnnotations(tt.annotations); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: OverridesFromAnnotations() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckOverridable(t *testing.T) {
+	tests := []struct {
+		name        string
+		overridable []string
+		overrides   Overrides
+		allowed     bool
+	}{
+		{"allowed", []string{OverrideBranch, OverrideTag}, Overrides{Branch: "develop"}, true},
+		{"parameter allowed", []string{"parameters.url"}, Overrides{Parameters: map[string]string{"url": "x"}}, true},
+		{"nothing overridable", nil, Overrides{Branch: "develop"}, false},
+		{"other field allowed", []string{OverrideTag}, Overrides{Branch: "develop"}, false},
+		{"other parameter allowed", []string{"parameters.url"}, Overrides{Parameters: map[string]string{"token": "x"}}, false},
+		{"one of two not allowed", []string{OverrideBranch}, Overrides{Branch: "develop", Subpath: "src"}, false},
+	}
+	for _, tt := range tests {
+		spec := PopulatorSpec{Overridable: tt.overridable}
+		err := spec.CheckOverridable(&tt.overrides)
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("%s: CheckOverridable() = %v, want allowed %v", tt.name, err, tt.allowed)
+		}
+	}
+}
+
+func TestOverridesApply(t *testing.T) {
+	gitSpec := func() PopulatorSpec {
+		return PopulatorSpec{Type: TypeGit, Mountpoint: "/data", Git: GitPopulator{Repo: "https://example.com/repo.git", Branch: "master"}}
+	}
+	tests := []struct {
+		name      string
+		spec      PopulatorSpec
+		overrides Overrides
+		want      []Source
+		wantErr   bool
+	}{
+		{
+			name:      "branch",
+			spec:      gitSpec(),
+			overrides: Overrides{Branch: "develop"},
+			want:      []Source{{Type: TypeGit, Git: &GitPopulator{Repo: "https://example.com/repo.git", Branch: "develop"}}},
+		},
+		{
+			name:      "tag",
+			spec:      gitSpec(),
+			overrides: Overrides{Tag: "v1.0"},
+			want:      []Source{{Type: TypeGit, Git: &GitPopulator{Repo: "https://example.com/repo.git", Branch: "master", Tag: "v1.0"}}},
+		},
+		{
+			name:      "subpath",
+			spec:      gitSpec(),
+			overrides: Overrides{Subpath: "src"},
+			want:      []Source{{Type: TypeGit, Subpath: "src", Git: &GitPopulator{Repo: "https://example.com/repo.git", Branch: "master"}}},
+		},
+		{
+			name: "parameters only go to sources without a block of their own",
+			spec: PopulatorSpec{Mountpoint: "/data", Sources: []Source{
+				{Type: "http", Subpath: "a", Parameters: map[string]string{"url": "old", "keep": "yes"}},
+				{Type: TypeGit, Subpath: "b", Git: &GitPopulator{Repo: "https://example.com/repo.git"}},
+			}},
+			overrides: Overrides{Parameters: map[string]string{"url": "new"}},
+			want: []Source{
+				{Type: "http", Subpath: "a", Parameters: map[string]string{"url": "new", "keep": "yes"}},
+				{Type: TypeGit, Subpath: "b", Git: &GitPopulator{Repo: "https://example.com/repo.git"}},
+			},
+		},
+		{
+			name:      "branch and tag",
+			spec:      gitSpec(),
+			overrides: Overrides{Branch: "develop", Tag: "v1.0"},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid branch",
+			spec:      gitSpec(),
+			overrides: Overrides{Branch: "--upload-pack=x"},
+			wantErr:   true,
+		},
+		{
+			name:      "subpath outside the mountpoint",
+			spec:      gitSpec(),
+			overrides: Overrides{Subpath: "../etc"},
+			wantErr:   true,
+		},
+		{
+			name:      "subpath with more than one source",
+			spec:      PopulatorSpec{Mountpoint: "/data", Sources: []Source{{Type: "http", Subpath: "a"}, {Type: "http", Subpath: "b"}}},
+			overrides: Overrides{Subpath: "c"},
+			wantErr:   true,
+		},
+		{
+			name:      "branch without a git source",
+			spec:      PopulatorSpec{Mountpoint: "/data", Sources: []Source{{Type: "http"}}},
+			overrides: Overrides{Branch: "develop"},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		spec := tt.spec
+		orig := (&Populator{Spec: tt.spec}).DeepCopy().Spec
+		err := tt.overrides.Apply(&spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Apply() = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if spec.Type != "" || !reflect.DeepEqual(spec.Sources, tt.want) {
+			t.Errorf("%s: Apply() gave type %q and sources %+v, want %+v", tt.name, spec.Type, spec.Sources, tt.want)
+		}
+		if !reflect.DeepEqual(tt.spec, orig) {
+			t.Errorf("%s: Apply() changed the sources it was given", tt.name)
+		}
+	}
+}
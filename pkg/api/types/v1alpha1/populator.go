@@ -1,6 +1,22 @@
 package v1alpha1
 
-import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+import (
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Kind is the kind a PVC dataSource uses to reference a Populator
+const Kind = "Populator"
+
+// The populator types we know how to run
+const (
+	TypeGit = "git"
+	TypeS3  = "s3"
+)
+
+// BuiltinTypes lists the populator types with their own source blocks, other types are registered with the
+// controller (see pkg/populator) and configured through Parameters
+var BuiltinTypes = []string{TypeGit, TypeS3}
 
 // GitPopulator provides a struct with the specific git information that might be desired
 type GitPopulator struct {
@@ -9,15 +25,99 @@ type GitPopulator struct {
 	Tag    string `json:"tag,omitempty"`
 }
 
+// S3Populator provides a struct with the bucket details for an S3 (or S3 compatible) object store
+type S3Populator struct {
+	Bucket   string `json:"bucket"`
+	Prefix   string `json:"prefix,omitempty"`   // Only objects under this key prefix are copied
+	Endpoint string `json:"endpoint,omitempty"` // Leave empty for AWS, set for S3 compatible stores
+	Region   string `json:"region,omitempty"`
+}
+
 // PopulatorSpec provides a struct that details the type of external data source we're working with, as well as where to mount
 // the data we're populating (ie root directory).  We also provide a mechanism to override the built in container images with
 // your own custom images.  Be warned, it's up to you to make sure you have proper enetry points etc here
 type PopulatorSpec struct {
-	//ImageOverride string       `json:"image_override"`
-	SecretRef  string       `json:"secret_ref"`
-	Type       string       `json:"type"`
-	Mountpoint string       `json:"mountpoint"`
-	Git        GitPopulator `json:"git"`
+	Image                   string            `json:"image,omitempty"` // Defaults to the built in image for Type
+	SecretRef               string            `json:"secret_ref"`
+	Type                    string            `json:"type,omitempty"`
+	Mountpoint              string            `json:"mountpoint"`
+	Subpath                 string            `json:"subpath,omitempty"` // Directory under Mountpoint the data is written to
+	Git                     GitPopulator      `json:"git"`
+	S3                      *S3Populator      `json:"s3,omitempty"`
+	Parameters              map[string]string `json:"parameters,omitempty"` // Settings for types without a source block
+	Sources                 []Source          `json:"sources,omitempty"`    // Use instead of Type for more than one source
+	Include                 []string          `json:"include,omitempty"`    // Populators in this namespace to populate before our own sources
+	TTLSecondsAfterFinished *int32            `json:"ttl_seconds_after_finished,omitempty"`
+	BackoffLimit            *int32            `json:"backoff_limit,omitempty"`           // Retries before the population is marked failed
+	ActiveDeadlineSeconds   *int64            `json:"active_deadline_seconds,omitempty"` // Overall time limit for the population
+	JobTemplate             *JobTemplate      `json:"job_template,omitempty"`
+	Ownership               *Ownership        `json:"ownership,omitempty"`
+	PostPopulate            *Hook             `json:"post_populate,omitempty"`
+	Cache                   *Cache            `json:"cache,omitempty"`
+	// RefreshSchedule is a cron schedule ("0 2 * * *", "@daily") to refresh the PVCs populated from this Populator
+	// on, in the controller's time zone
+	RefreshSchedule string `json:"refresh_schedule,omitempty"`
+	// Overridable lists what PVCs may override with annotations (see AnnOverrideBranch and friends): "branch",
+	// "tag", "subpath" and "parameters.<name>"
+	Overridable []string `json:"overridable,omitempty"`
+}
+
+// The ways a populated volume can be copied, see Cache
+const (
+	CacheModeClone    = "clone"
+	CacheModeSnapshot = "snapshot"
+)
+
+// Cache has the controller populate a golden volume once per Populator generation and copy it for every PVC that
+// uses the Populator, instead of running a job for each one.  Mode is CacheModeClone (a CSI volume clone of the
+// golden PVC) or CacheModeSnapshot (a VolumeSnapshot of it, restored for each PVC)
+type Cache struct {
+	Mode                    string `json:"mode"`
+	VolumeSnapshotClassName string `json:"volume_snapshot_class_name,omitempty"`
+}
+
+// Hook is a user supplied step run against the populated volume once all the sources have been written, e.g. to
+// unpack an archive, build an index or run migrations.  It runs in the mountpoint and if it fails the population
+// fails
+type Hook struct {
+	Image   string   `json:"image"`
+	Command []string `json:"command,omitempty"` // Defaults to the image's entrypoint
+	Args    []string `json:"args,omitempty"`
+}
+
+// Source is one entry in PopulatorSpec.Sources, each source is written to its own Subpath under the Populator's
+// Mountpoint and they're populated one after the other in the order they're listed
+type Source struct {
+	Type    string        `json:"type"`
+	Subpath string        `json:"subpath,omitempty"`
+	Image   string        `json:"image,omitempty"` // Defaults to the built in image for Type
+	Git     *GitPopulator `json:"git,omitempty"`
+	S3      *S3Populator  `json:"s3,omitempty"`
+	// Parameters holds the settings for types without a source block of their own
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// Ownership sets who owns the populated data and its permissions, so consumers that don't run as the populator
+// image's user (usually root) can still use it.  GID is applied as the pod's fsGroup, UID and Mode are applied
+// recursively by a chown/chmod step once population has finished
+type Ownership struct {
+	UID  *int64 `json:"uid,omitempty"`
+	GID  *int64 `json:"gid,omitempty"`
+	Mode string `json:"mode,omitempty"` // chmod mode, octal ("0775") or symbolic ("g+rwX")
+}
+
+// JobTemplate holds the pod level settings that are merged into the populator job, anything left unset falls
+// back to the controller wide defaults and then to whatever the cluster does by default
+type JobTemplate struct {
+	Resources          core_v1.ResourceRequirements   `json:"resources,omitempty"`
+	NodeSelector       map[string]string              `json:"node_selector,omitempty"`
+	Affinity           *core_v1.Affinity              `json:"affinity,omitempty"`
+	Tolerations        []core_v1.Toleration           `json:"tolerations,omitempty"`
+	PriorityClassName  string                         `json:"priority_class_name,omitempty"`
+	ServiceAccountName string                         `json:"service_account_name,omitempty"`
+	RunAsUser          *int64                         `json:"run_as_user,omitempty"`
+	FSGroup            *int64                         `json:"fs_group,omitempty"`
+	ImagePullSecrets   []core_v1.LocalObjectReference `json:"image_pull_secrets,omitempty"`
 }
 
 // Populator represents our CRD Object.  A populator is a DataSource used to pre-populate PVCs upon creation
@@ -25,7 +125,14 @@ type Populator struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec PopulatorSpec `json:"spec"`
+	Spec   PopulatorSpec   `json:"spec"`
+	Status PopulatorStatus `json:"status,omitempty"`
+}
+
+// PopulatorStatus is written by the controller, Conditions holds the result of the checks it runs against the
+// Populator's sources (see ConditionSourceReachable)
+type PopulatorStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // PopulatorList provides a type of multiple Populators
@@ -35,3 +142,36 @@ type PopulatorList struct {
 
 	Items []Populator `json:"items"`
 }
+
+// IsPopulatorDataSource returns true if the PVC dataSource reference points at a Populator or a ClusterPopulator,
+// as opposed to a VolumeSnapshot, another PVC or somebody else's populator
+func IsPopulatorDataSource(ds *core_v1.TypedLocalObjectReference) bool {
+	if ds == nil || ds.APIGroup == nil {
+		return false
+	}
+	return *ds.APIGroup == GroupName && (ds.Kind == Kind || ds.Kind == ClusterKind)
+}
+
+// AllSources returns the Populator's own sources to populate in order, a Populator using the single Type and its
+// source block is returned as a list of one so callers don't have to care which form was used.  Included
+// Populators aren't resolved here, that needs an API lookup
+func (s *PopulatorSpec) AllSources() []Source {
+	if len(s.Sources) > 0 {
+		return s.Sources
+	}
+	if s.Type == "" {
+		return nil
+	}
+	src := Source{
+		Type:       s.Type,
+		Subpath:    s.Subpath,
+		Image:      s.Image,
+		S3:         s.S3,
+		Parameters: s.Parameters,
+	}
+	if s.Type == TypeGit {
+		git := s.Git
+		src.Git = &git
+	}
+	return []Source{src}
+}
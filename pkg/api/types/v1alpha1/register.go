@@ -20,6 +20,14 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&Populator{},
 		&PopulatorList{},
+		&PopulatorClass{},
+		&PopulatorClassList{},
+		&PopulationRequest{},
+		&PopulationRequestList{},
+		&PopulatorGrant{},
+		&PopulatorGrantList{},
+		&ClusterPopulator{},
+		&ClusterPopulatorList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
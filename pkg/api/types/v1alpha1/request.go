@@ -0,0 +1,48 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PopulationRequest runs a Populator against an existing PVC once, whenever it's created, rather than when the
+// PVC is.  The PVC doesn't need to reference the Populator (or any Populator) in its dataSource.  Each request
+// records the outcome of its own run, so they double as an audit trail of what was written to the volume
+type PopulationRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PopulationRequestSpec   `json:"spec"`
+	Status PopulationRequestStatus `json:"status,omitempty"`
+}
+
+// PopulationRequestSpec names the PVC to populate and the Populator to do it with, both in the request's
+// namespace.  Overrides are applied to the Populator's own sources, not the ones it includes
+type PopulationRequestSpec struct {
+	PVC       string     `json:"pvc"`
+	Populator string     `json:"populator"`
+	Overrides *Overrides `json:"overrides,omitempty"`
+}
+
+// PopulationRequestStatus is written by the controller.  Phase is one of the Population* values, a request is
+// Pending until the PVC is free (not being populated by anything else)
+type PopulationRequestStatus struct {
+	Phase          string       `json:"phase,omitempty"`
+	Job            string       `json:"job,omitempty"`
+	Message        string       `json:"message,omitempty"`
+	StartTime      *metav1.Time `json:"start_time,omitempty"`
+	CompletionTime *metav1.Time `json:"completion_time,omitempty"`
+	ContentDigest  string       `json:"content_digest,omitempty"` // See AnnContentDigest
+}
+
+// PopulationRequestList provides a type of multiple PopulationRequests
+type PopulationRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []PopulationRequest `json:"items"`
+}
+
+// Finished returns true once the request's job has succeeded or failed, it's never run again after that
+func (r *PopulationRequest) Finished() bool {
+	return r.Status.Phase == PopulationSucceeded || r.Status.Phase == PopulationFailed
+}
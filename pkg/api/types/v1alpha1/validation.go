@@ -0,0 +1,184 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// modeRegexp matches the chmod modes we accept, octal or a comma separated list of symbolic clauses
+var modeRegexp = regexp.MustCompile(`^([0-7]{3,4}|[ugoa]*[-+=][rwxXst]*(,[ugoa]*[-+=][rwxXst]*)*)$`)
+
+// Validate checks a Populator for the mistakes we'd otherwise only find out about when a PVC tries to use it
+// and the job fails.  It can't check anything that needs an API lookup (like SecretRef existing), that's up
+// to the caller
+func (p *Populator) Validate() field.ErrorList {
+	return p.Spec.Validate(field.NewPath("spec"))
+}
+
+// Validate checks the spec fields, fldPath is the path of the spec within its parent object
+func (s *PopulatorSpec) Validate(fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if s.Mountpoint == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("mountpoint"), "the directory to populate is required"))
+	} else if !path.IsAbs(s.Mountpoint) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("mountpoint"), s.Mountpoint, "must be an absolute path"))
+	}
+
+	if s.TTLSecondsAfterFinished != nil && *s.TTLSecondsAfterFinished < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("ttl_seconds_after_finished"), *s.TTLSecondsAfterFinished, "must not be negative"))
+	}
+	if s.BackoffLimit != nil && *s.BackoffLimit < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("backoff_limit"), *s.BackoffLimit, "must not be negative"))
+	}
+	if s.ActiveDeadlineSeconds != nil && *s.ActiveDeadlineSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("active_deadline_seconds"), *s.ActiveDeadlineSeconds, "must be greater than zero"))
+	}
+
+	if o := s.Ownership; o != nil {
+		if o.UID != nil && *o.UID < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("ownership", "uid"), *o.UID, "must not be negative"))
+		}
+		if o.GID != nil && *o.GID < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("ownership", "gid"), *o.GID, "must not be negative"))
+		}
+		if t := s.JobTemplate; o.GID != nil && t != nil && t.FSGroup != nil && *t.FSGroup != *o.GID {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("ownership", "gid"), *o.GID, "conflicts with job_template.fs_group, set one of them or make them the same"))
+		}
+		if o.Mode != "" && !modeRegexp.MatchString(o.Mode) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("ownership", "mode"), o.Mode, "must be an octal or symbolic chmod mode"))
+		}
+	}
+
+	if s.PostPopulate != nil && s.PostPopulate.Image == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("post_populate", "image"), "an image is required for the post populate hook"))
+	}
+
+	if c := s.Cache; c != nil {
+		switch c.Mode {
+		case CacheModeClone:
+			if c.VolumeSnapshotClassName != "" {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child("cache", "volume_snapshot_class_name"), "only used with the snapshot mode"))
+			}
+		case CacheModeSnapshot:
+		default:
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("cache", "mode"), c.Mode, []string{CacheModeClone, CacheModeSnapshot}))
+		}
+	}
+
+	for i, f := range s.Overridable {
+		if !overridableRegexp.MatchString(f) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("overridable").Index(i), f, "must be branch, tag, subpath or parameters.<name>"))
+		}
+	}
+
+	switch {
+	case s.Type != "" && len(s.Sources) > 0:
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("sources"), "only one of type or sources may be set"))
+	case len(s.Sources) > 0:
+		for i := range s.Sources {
+			allErrs = append(allErrs, s.Sources[i].Validate(fldPath.Child("sources").Index(i))...)
+		}
+		allErrs = append(allErrs, ValidateSubpaths(s.Sources, fldPath.Child("sources"))...)
+	case s.Type != "":
+		// the single source form keeps its fields directly in the spec
+		src := s.AllSources()[0]
+		allErrs = append(allErrs, src.Validate(fldPath)...)
+	case len(s.Include) == 0:
+		allErrs = append(allErrs, field.Required(fldPath.Child("type"), "one of type, sources or include is required"))
+	}
+
+	for i, name := range s.Include {
+		if name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("include").Index(i), "a Populator name is required"))
+		}
+	}
+	return allErrs
+}
+
+// ValidateSubpaths makes sure no two sources are written to the same directory or one inside the other, a source
+// can't be cloned into a directory another one has already filled and refreshing the outer one would trip over the
+// inner one.  fldPath is the path of the list
+func ValidateSubpaths(sources []Source, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for i := range sources {
+		for j := 0; j < i; j++ {
+			if SubpathsOverlap(sources[i].Subpath, sources[j].Subpath) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("subpath"), sources[i].Subpath,
+					fmt.Sprintf("overlaps the subpath of source %d, each source needs a directory of its own", j)))
+				break
+			}
+		}
+	}
+	return allErrs
+}
+
+// SubpathsOverlap returns true if a and b are the same directory or one is inside the other, an empty subpath is
+// the mountpoint itself
+func SubpathsOverlap(a, b string) bool {
+	a, b = path.Clean("/"+a), path.Clean("/"+b)
+	if a == "/" || b == "/" || a == b {
+		return true
+	}
+	return strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+// ValidateGitRef checks a branch or tag name against the rules of git check-ref-format, and rejects names git
+// would take as an option.  The ref ends up on the git populator's command line, anything else could be used to
+// smuggle options into the clone
+func ValidateGitRef(ref string, fldPath *field.Path) field.ErrorList {
+	if msg := gitRefError(ref); msg != "" {
+		return field.ErrorList{field.Invalid(fldPath, ref, msg)}
+	}
+	return nil
+}
+
+func gitRefError(ref string) string {
+	switch {
+	case ref == "":
+		return "must not be empty"
+	case ref == "@":
+		return `must not be "@"`
+	case strings.HasPrefix(ref, "-"):
+		return `must not start with "-"`
+	case strings.HasPrefix(ref, "/") || strings.HasSuffix(ref, "/"):
+		return `must not start or end with "/"`
+	case strings.HasSuffix(ref, "."):
+		return `must not end with "."`
+	}
+	for _, s := range []string{"..", "//", "@{", `\`} {
+		if strings.Contains(ref, s) {
+			return fmt.Sprintf("must not contain %q", s)
+		}
+	}
+	for _, r := range ref {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[", r) {
+			return fmt.Sprintf("must not contain %q", r)
+		}
+	}
+	for _, c := range strings.Split(ref, "/") {
+		if strings.HasPrefix(c, ".") || strings.HasSuffix(c, ".lock") {
+			return `no "/" separated part may start with "." or end with ".lock"`
+		}
+	}
+	return ""
+}
+
+// Validate checks a single source, fldPath is the path of the object holding its type and source block
+func (s *Source) Validate(fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if s.Subpath != "" && (path.IsAbs(s.Subpath) || strings.HasPrefix(path.Clean(s.Subpath), "..")) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("subpath"), s.Subpath, "must be a relative path inside the mountpoint"))
+	}
+
+	// what the type needs is checked by the type itself, which is registered with the controller
+	if s.Type == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("type"), "a populator type is required"))
+	}
+	return allErrs
+}
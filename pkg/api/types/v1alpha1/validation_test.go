@@ -0,0 +1,173 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidateGitRef(t *testing.T) {
+	tests := []struct {
+		ref   string
+		valid bool
+	}{
+		{"master", true},
+		{"release/1.0", true},
+		{"v1.2.3", true},
+		{"feature-x", true},
+		{"", false},
+		{"@", false},
+		{"-b", false},
+		{"--upload-pack=touch /tmp/x", false},
+		{"/master", false},
+		{"master/", false},
+		{"master.", false},
+		{"a..b", false},
+		{"a//b", false},
+		{"a@{1}", false},
+		{`a\b`, false},
+		{"a b", false},
+		{"a~1", false},
+		{"a^", false},
+		{"a:b", false},
+		{"a?", false},
+		{"a*", false},
+		{"a[b", false},
+		{"a\tb", false},
+		{".hidden", false},
+		{"release/.hidden", false},
+		{"branch.lock", false},
+		{"release/x.lock/y", false},
+	}
+	for _, tt := range tests {
+		errs := ValidateGitRef(tt.ref, field.NewPath("ref"))
+		if valid := len(errs) == 0; valid != tt.valid {
+			t.Errorf("ValidateGitRef(%q) = %v, want valid %v", tt.ref, errs, tt.valid)
+		}
+	}
+}
+
+func TestSubpathsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		overlap bool
+	}{
+		{"src", "tools", false},
+		{"src", "src2", false},
+		{"a/b", "a/c", false},
+		{"src", "src", true},
+		{"src", "src/", true},
+		{"src", "./src", true},
+		{"src", "src/vendor", true},
+		{"src/vendor", "src", true},
+		{"", "src", true},
+		{".", "src", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		if got := SubpathsOverlap(tt.a, tt.b); got != tt.overlap {
+			t.Errorf("SubpathsOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.overlap)
+		}
+	}
+}
+
+func TestValidateSubpaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		subpaths []string
+		errs     int
+	}{
+		{"disjoint", []string{"src", "tools/csi", "docs"}, 0},
+		{"duplicate", []string{"src", "src"}, 1},
+		{"nested", []string{"src", "src/vendor"}, 1},
+		{"nested the other way", []string{"src/vendor", "src"}, 1},
+		{"root and a subpath", []string{"", "src"}, 1},
+		{"one error per source", []string{"src", "src", "src"}, 2},
+	}
+	for _, tt := range tests {
+		var sources []Source
+		for _, s := range tt.subpaths {
+			sources = append(sources, Source{Type: TypeGit, Subpath: s})
+		}
+		if errs := ValidateSubpaths(sources, field.NewPath("sources")); len(errs) != tt.errs {
+			t.Errorf("%s: got %d errors (%v), want %d", tt.name, len(errs), errs, tt.errs)
+		}
+	}
+}
+
+func TestPopulatorSpecValidate(t *testing.T) {
+	gid := int64(1000)
+	otherGID := int64(2000)
+	tests := []struct {
+		name  string
+		spec  PopulatorSpec
+		valid bool
+	}{
+		{
+			name:  "single source",
+			spec:  PopulatorSpec{Type: TypeGit, Mountpoint: "/data", Git: GitPopulator{Repo: "https://example.com/repo.git"}},
+			valid: true,
+		},
+		{
+			name:  "sources",
+			spec:  PopulatorSpec{Mountpoint: "/data", Sources: []Source{{Type: TypeGit, Subpath: "a"}, {Type: TypeGit, Subpath: "b"}}},
+			valid: true,
+		},
+		{
+			name:  "include only",
+			spec:  PopulatorSpec{Mountpoint: "/data", Include: []string{"base"}},
+			valid: true,
+		},
+		{
+			name: "no mountpoint",
+			spec: PopulatorSpec{Type: TypeGit},
+		},
+		{
+			name: "relative mountpoint",
+			spec: PopulatorSpec{Type: TypeGit, Mountpoint: "data"},
+		},
+		{
+			name: "type and sources",
+			spec: PopulatorSpec{Type: TypeGit, Mountpoint: "/data", Sources: []Source{{Type: TypeGit}}},
+		},
+		{
+			name: "nothing to populate",
+			spec: PopulatorSpec{Mountpoint: "/data"},
+		},
+		{
+			name: "source without a type",
+			spec: PopulatorSpec{Mountpoint: "/data", Sources: []Source{{Subpath: "a"}}},
+		},
+		{
+			name: "subpath outside the mountpoint",
+			spec: PopulatorSpec{Mountpoint: "/data", Sources: []Source{{Type: TypeGit, Subpath: "../etc"}}},
+		},
+		{
+			name: "overlapping subpaths",
+			spec: PopulatorSpec{Mountpoint: "/data", Sources: []Source{{Type: TypeGit, Subpath: "a"}, {Type: TypeGit, Subpath: "a/b"}}},
+		},
+		{
+			name: "bad overridable",
+			spec: PopulatorSpec{Type: TypeGit, Mountpoint: "/data", Overridable: []string{"repo"}},
+		},
+		{
+			name: "bad mode",
+			spec: PopulatorSpec{Type: TypeGit, Mountpoint: "/data", Ownership: &Ownership{Mode: "rwx"}},
+		},
+		{
+			name:  "gid matching fs_group",
+			spec:  PopulatorSpec{Type: TypeGit, Mountpoint: "/data", Ownership: &Ownership{GID: &gid}, JobTemplate: &JobTemplate{FSGroup: &gid}},
+			valid: true,
+		},
+		{
+			name: "gid conflicting with fs_group",
+			spec: PopulatorSpec{Type: TypeGit, Mountpoint: "/data", Ownership: &Ownership{GID: &gid}, JobTemplate: &JobTemplate{FSGroup: &otherGID}},
+		},
+	}
+	for _, tt := range tests {
+		errs := tt.spec.Validate(field.NewPath("spec"))
+		if valid := len(errs) == 0; valid != tt.valid {
+			t.Errorf("%s: Validate() = %v, want valid %v", tt.name, errs, tt.valid)
+		}
+	}
+}
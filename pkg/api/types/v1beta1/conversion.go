@@ -0,0 +1,242 @@
+package v1beta1
+
+import (
+	"fmt"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+)
+
+// ConvertFromV1alpha1 converts a v1alpha1 Populator into out.  The flat Type string and its matching source
+// block become the single member of the source union, blocks that don't match Type are dropped since
+// v1alpha1 never used them.  Sources convert entry by entry
+func ConvertFromV1alpha1(in *v1alpha1.Populator, out *Populator) error {
+	out.TypeMeta = in.TypeMeta
+	out.APIVersion = SchemeGroupVersion.String()
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	// the conditions are the same type in both versions
+	out.Status.Conditions = in.DeepCopy().Status.Conditions
+
+	out.Spec = PopulatorSpec{
+		Mountpoint:      in.Spec.Mountpoint,
+		SecretRef:       in.Spec.SecretRef,
+		RefreshSchedule: in.Spec.RefreshSchedule,
+	}
+	if in.Spec.Include != nil {
+		out.Spec.Include = append([]string{}, in.Spec.Include...)
+	}
+	if in.Spec.Overridable != nil {
+		out.Spec.Overridable = append([]string{}, in.Spec.Overridable...)
+	}
+	if in.Spec.TTLSecondsAfterFinished != nil {
+		ttl := *in.Spec.TTLSecondsAfterFinished
+		out.Spec.TTLSecondsAfterFinished = &ttl
+	}
+	if in.Spec.BackoffLimit != nil {
+		backoff := *in.Spec.BackoffLimit
+		out.Spec.BackoffLimit = &backoff
+	}
+	if in.Spec.ActiveDeadlineSeconds != nil {
+		deadline := *in.Spec.ActiveDeadlineSeconds
+		out.Spec.ActiveDeadlineSeconds = &deadline
+	}
+	out.Spec.JobTemplate = ConvertJobTemplateFromV1alpha1(in.Spec.JobTemplate)
+	if in.Spec.Ownership != nil {
+		o := in.Spec.Ownership.DeepCopy()
+		out.Spec.Ownership = &Ownership{UID: o.UID, GID: o.GID, Mode: o.Mode}
+	}
+	if in.Spec.PostPopulate != nil {
+		h := in.Spec.PostPopulate.DeepCopy()
+		out.Spec.PostPopulate = &Hook{Image: h.Image, Command: h.Command, Args: h.Args}
+	}
+	if c := in.Spec.Cache; c != nil {
+		out.Spec.Cache = &Cache{Mode: c.Mode, VolumeSnapshotClassName: c.VolumeSnapshotClassName}
+	}
+
+	if len(in.Spec.Sources) == 0 && in.Spec.Type == "" {
+		// nothing but includes
+		return nil
+	}
+	if len(in.Spec.Sources) == 0 {
+		src, err := convertSourceFromV1alpha1(&in.Spec.AllSources()[0])
+		if err != nil {
+			return fmt.Errorf("populator %s/%s: %v", in.Namespace, in.Name, err)
+		}
+		out.Spec.Source = src
+		return nil
+	}
+	for i := range in.Spec.Sources {
+		src, err := convertSourceFromV1alpha1(&in.Spec.Sources[i])
+		if err != nil {
+			return fmt.Errorf("populator %s/%s sources[%d]: %v", in.Namespace, in.Name, i, err)
+		}
+		out.Spec.Sources = append(out.Spec.Sources, *src)
+	}
+	return nil
+}
+
+// convertSourceFromV1alpha1 turns a v1alpha1 Type and source block into the v1beta1 union
+func convertSourceFromV1alpha1(in *v1alpha1.Source) (*PopulatorSource, error) {
+	out := &PopulatorSource{Subpath: in.Subpath, Image: in.Image, Parameters: copyParameters(in.Parameters)}
+	switch in.Type {
+	case v1alpha1.TypeGit:
+		if in.Git == nil {
+			return nil, fmt.Errorf("type git but no git source")
+		}
+		out.Git = &GitSource{
+			Repo:   in.Git.Repo,
+			Branch: in.Git.Branch,
+			Tag:    in.Git.Tag,
+		}
+	case v1alpha1.TypeS3:
+		if in.S3 == nil {
+			return nil, fmt.Errorf("type s3 but no s3 source")
+		}
+		out.S3 = &S3Source{
+			Bucket:   in.S3.Bucket,
+			Prefix:   in.S3.Prefix,
+			Endpoint: in.S3.Endpoint,
+			Region:   in.S3.Region,
+		}
+	case "":
+		return nil, fmt.Errorf("no type")
+	default:
+		out.SourceType = in.Type
+	}
+	return out, nil
+}
+
+// ConvertToV1alpha1 converts a v1beta1 Populator into out, Type is set from whichever source is present
+func ConvertToV1alpha1(in *Populator, out *v1alpha1.Populator) error {
+	out.TypeMeta = in.TypeMeta
+	out.APIVersion = v1alpha1.SchemeGroupVersion.String()
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Status.Conditions = in.DeepCopy().Status.Conditions
+
+	out.Spec = v1alpha1.PopulatorSpec{
+		Mountpoint:      in.Spec.Mountpoint,
+		SecretRef:       in.Spec.SecretRef,
+		RefreshSchedule: in.Spec.RefreshSchedule,
+	}
+	if in.Spec.Include != nil {
+		out.Spec.Include = append([]string{}, in.Spec.Include...)
+	}
+	if in.Spec.Overridable != nil {
+		out.Spec.Overridable = append([]string{}, in.Spec.Overridable...)
+	}
+	if in.Spec.TTLSecondsAfterFinished != nil {
+		ttl := *in.Spec.TTLSecondsAfterFinished
+		out.Spec.TTLSecondsAfterFinished = &ttl
+	}
+	if in.Spec.BackoffLimit != nil {
+		backoff := *in.Spec.BackoffLimit
+		out.Spec.BackoffLimit = &backoff
+	}
+	if in.Spec.ActiveDeadlineSeconds != nil {
+		deadline := *in.Spec.ActiveDeadlineSeconds
+		out.Spec.ActiveDeadlineSeconds = &deadline
+	}
+	out.Spec.JobTemplate = ConvertJobTemplateToV1alpha1(in.Spec.JobTemplate)
+	if in.Spec.Ownership != nil {
+		o := in.Spec.Ownership.DeepCopy()
+		out.Spec.Ownership = &v1alpha1.Ownership{UID: o.UID, GID: o.GID, Mode: o.Mode}
+	}
+	if in.Spec.PostPopulate != nil {
+		h := in.Spec.PostPopulate.DeepCopy()
+		out.Spec.PostPopulate = &v1alpha1.Hook{Image: h.Image, Command: h.Command, Args: h.Args}
+	}
+	if c := in.Spec.Cache; c != nil {
+		out.Spec.Cache = &v1alpha1.Cache{Mode: c.Mode, VolumeSnapshotClassName: c.VolumeSnapshotClassName}
+	}
+
+	if in.Spec.Source != nil && len(in.Spec.Sources) > 0 {
+		return fmt.Errorf("populator %s/%s must set only one of source and sources", in.Namespace, in.Name)
+	}
+	if in.Spec.Source != nil {
+		src, err := convertSourceToV1alpha1(in.Spec.Source)
+		if err != nil {
+			return fmt.Errorf("populator %s/%s: %v", in.Namespace, in.Name, err)
+		}
+		// the single source form keeps its fields directly in the v1alpha1 spec
+		out.Spec.Type = src.Type
+		out.Spec.Subpath = src.Subpath
+		out.Spec.Image = src.Image
+		out.Spec.S3 = src.S3
+		out.Spec.Parameters = src.Parameters
+		if src.Git != nil {
+			out.Spec.Git = *src.Git
+		}
+		return nil
+	}
+	if len(in.Spec.Sources) == 0 && len(in.Spec.Include) == 0 {
+		return fmt.Errorf("populator %s/%s must set one of source, sources or include", in.Namespace, in.Name)
+	}
+	for i := range in.Spec.Sources {
+		src, err := convertSourceToV1alpha1(&in.Spec.Sources[i])
+		if err != nil {
+			return fmt.Errorf("populator %s/%s sources[%d]: %v", in.Namespace, in.Name, i, err)
+		}
+		out.Spec.Sources = append(out.Spec.Sources, src)
+	}
+	return nil
+}
+
+// convertSourceToV1alpha1 turns the v1beta1 union into a v1alpha1 Type and source block
+func convertSourceToV1alpha1(in *PopulatorSource) (v1alpha1.Source, error) {
+	out := v1alpha1.Source{Type: in.Type(), Subpath: in.Subpath, Image: in.Image, Parameters: copyParameters(in.Parameters)}
+	switch out.Type {
+	case v1alpha1.TypeGit:
+		out.Git = &v1alpha1.GitPopulator{
+			Repo:   in.Git.Repo,
+			Branch: in.Git.Branch,
+			Tag:    in.Git.Tag,
+		}
+	case v1alpha1.TypeS3:
+		out.S3 = &v1alpha1.S3Populator{
+			Bucket:   in.S3.Bucket,
+			Prefix:   in.S3.Prefix,
+			Endpoint: in.S3.Endpoint,
+			Region:   in.S3.Region,
+		}
+	case "":
+		return out, fmt.Errorf("exactly one of git, s3 or type must be set")
+	}
+	return out, nil
+}
+
+// ConvertJobTemplateFromV1alpha1 returns a v1beta1 copy of a v1alpha1 JobTemplate, nil stays nil
+func ConvertJobTemplateFromV1alpha1(in *v1alpha1.JobTemplate) *JobTemplate {
+	if in == nil {
+		return nil
+	}
+	in = in.DeepCopy()
+	return &JobTemplate{
+		Resources:          in.Resources,
+		NodeSelector:       in.NodeSelector,
+		Affinity:           in.Affinity,
+		Tolerations:        in.Tolerations,
+		PriorityClassName:  in.PriorityClassName,
+		ServiceAccountName: in.ServiceAccountName,
+		RunAsUser:          in.RunAsUser,
+		FSGroup:            in.FSGroup,
+		ImagePullSecrets:   in.ImagePullSecrets,
+	}
+}
+
+// ConvertJobTemplateToV1alpha1 returns a v1alpha1 copy of a v1beta1 JobTemplate, nil stays nil
+func ConvertJobTemplateToV1alpha1(in *JobTemplate) *v1alpha1.JobTemplate {
+	if in == nil {
+		return nil
+	}
+	in = in.DeepCopy()
+	return &v1alpha1.JobTemplate{
+		Resources:          in.Resources,
+		NodeSelector:       in.NodeSelector,
+		Affinity:           in.Affinity,
+		Tolerations:        in.Tolerations,
+		PriorityClassName:  in.PriorityClassName,
+		ServiceAccountName: in.ServiceAccountName,
+		RunAsUser:          in.RunAsUser,
+		FSGroup:            in.FSGroup,
+		ImagePullSecrets:   in.ImagePullSecrets,
+	}
+}
@@ -0,0 +1,130 @@
+package v1beta1
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConversionRoundTrip(t *testing.T) {
+	ttl := int32(60)
+	meta := metav1.ObjectMeta{Name: "pop", Namespace: "ns", Labels: map[string]string{"app": "demo"}}
+	tests := []struct {
+		name string
+		spec v1alpha1.PopulatorSpec
+	}{
+		{
+			name: "git",
+			spec: v1alpha1.PopulatorSpec{
+				Type:                    v1alpha1.TypeGit,
+				Mountpoint:              "/src",
+				Image:                   "my/git",
+				SecretRef:               "creds",
+				TTLSecondsAfterFinished: &ttl,
+				Git:                     v1alpha1.GitPopulator{Repo: "https://example.com/repo.git", Branch: "main"},
+			},
+		},
+		{
+			name: "git tag",
+			spec: v1alpha1.PopulatorSpec{
+				Type:       v1alpha1.TypeGit,
+				Mountpoint: "/src",
+				Git:        v1alpha1.GitPopulator{Repo: "https://example.com/repo.git", Tag: "v1.0"},
+			},
+		},
+		{
+			name: "s3",
+			spec: v1alpha1.PopulatorSpec{
+				Type:       v1alpha1.TypeS3,
+				Mountpoint: "/data",
+				SecretRef:  "aws",
+				S3:         &v1alpha1.S3Populator{Bucket: "b", Prefix: "p/", Endpoint: "https://minio:9000", Region: "us-east-1"},
+			},
+		},
+		{
+			name: "subpath",
+			spec: v1alpha1.PopulatorSpec{
+				Type:       v1alpha1.TypeGit,
+				Mountpoint: "/src",
+				Subpath:    "app",
+				Git:        v1alpha1.GitPopulator{Repo: "https://example.com/repo.git", Branch: "main"},
+			},
+		},
+		{
+			name: "registered type",
+			spec: v1alpha1.PopulatorSpec{
+				Type:       "http",
+				Mountpoint: "/data",
+				Parameters: map[string]string{"url": "https://example.com/data.tar.gz"},
+			},
+		},
+		{
+			name: "sources",
+			spec: v1alpha1.PopulatorSpec{
+				Mountpoint: "/src",
+				Sources: []v1alpha1.Source{
+					{Type: v1alpha1.TypeGit, Subpath: "app", Git: &v1alpha1.GitPopulator{Repo: "https://example.com/app.git", Branch: "main"}},
+					{Type: v1alpha1.TypeS3, Subpath: "assets", Image: "my/s3", S3: &v1alpha1.S3Populator{Bucket: "b"}},
+					{Type: "http", Subpath: "models", Parameters: map[string]string{"url": "https://example.com/model.bin"}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		in := &v1alpha1.Populator{
+			TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "Populator"},
+			ObjectMeta: meta,
+			Spec:       tt.spec,
+		}
+		beta := &Populator{}
+		if err := ConvertFromV1alpha1(in, beta); err != nil {
+			t.Errorf("%s: ConvertFromV1alpha1: %v", tt.name, err)
+			continue
+		}
+		if beta.APIVersion != SchemeGroupVersion.String() {
+			t.Errorf("%s: converted apiVersion %s, want %s", tt.name, beta.APIVersion, SchemeGroupVersion)
+		}
+		if tt.spec.Type != "" && (beta.Spec.Source == nil || beta.Spec.Source.Type() != tt.spec.Type) {
+			t.Errorf("%s: converted source %+v, want a %s source", tt.name, beta.Spec.Source, tt.spec.Type)
+		}
+		if len(beta.Spec.Sources) != len(tt.spec.Sources) {
+			t.Errorf("%s: converted %d sources, want %d", tt.name, len(beta.Spec.Sources), len(tt.spec.Sources))
+		}
+		out := &v1alpha1.Populator{}
+		if err := ConvertToV1alpha1(beta, out); err != nil {
+			t.Errorf("%s: ConvertToV1alpha1: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(in, out) {
+			t.Errorf("%s: round trip changed the Populator\n got: %+v\nwant: %+v", tt.name, out, in)
+		}
+	}
+}
+
+func TestConversionErrors(t *testing.T) {
+	alpha := []v1alpha1.PopulatorSpec{
+		{Type: v1alpha1.TypeS3},
+		{Sources: []v1alpha1.Source{{Type: v1alpha1.TypeGit}}},
+	}
+	for _, spec := range alpha {
+		if err := ConvertFromV1alpha1(&v1alpha1.Populator{Spec: spec}, &Populator{}); err == nil {
+			t.Errorf("ConvertFromV1alpha1(%+v) succeeded, want an error", spec)
+		}
+	}
+
+	git := &PopulatorSource{Git: &GitSource{Repo: "https://example.com/repo.git"}}
+	beta := []PopulatorSpec{
+		{},
+		{Source: &PopulatorSource{}},
+		{Source: &PopulatorSource{Git: &GitSource{Repo: "https://example.com/repo.git"}, S3: &S3Source{Bucket: "b"}}},
+		{Source: git, Sources: []PopulatorSource{*git}},
+		{Sources: []PopulatorSource{*git, {}}},
+	}
+	for _, spec := range beta {
+		if err := ConvertToV1alpha1(&Populator{Spec: spec}, &v1alpha1.Populator{}); err == nil {
+			t.Errorf("ConvertToV1alpha1(%+v) succeeded, want an error", spec)
+		}
+	}
+}
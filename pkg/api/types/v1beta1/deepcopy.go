@@ -0,0 +1,201 @@
+package v1beta1
+
+import (
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto copies all properties of this object into another object of the
+// same type that is provided as a pointer.
+func (in *Populator) DeepCopyInto(out *Populator) {
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopyInto copies the status and its conditions into out
+func (in *PopulatorStatus) DeepCopyInto(out *PopulatorStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(in.Conditions))
+		for i := range in.Conditions {
+			in.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+// DeepCopyInto copies the spec, including whichever source is set, into out
+func (in *PopulatorSpec) DeepCopyInto(out *PopulatorSpec) {
+	*out = *in
+	if in.Source != nil {
+		out.Source = new(PopulatorSource)
+		in.Source.DeepCopyInto(out.Source)
+	}
+	if in.Sources != nil {
+		out.Sources = make([]PopulatorSource, len(in.Sources))
+		for i := range in.Sources {
+			in.Sources[i].DeepCopyInto(&out.Sources[i])
+		}
+	}
+	if in.Include != nil {
+		out.Include = make([]string, len(in.Include))
+		copy(out.Include, in.Include)
+	}
+	if in.Overridable != nil {
+		out.Overridable = make([]string, len(in.Overridable))
+		copy(out.Overridable, in.Overridable)
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		out.TTLSecondsAfterFinished = new(int32)
+		*out.TTLSecondsAfterFinished = *in.TTLSecondsAfterFinished
+	}
+	if in.BackoffLimit != nil {
+		out.BackoffLimit = new(int32)
+		*out.BackoffLimit = *in.BackoffLimit
+	}
+	if in.ActiveDeadlineSeconds != nil {
+		out.ActiveDeadlineSeconds = new(int64)
+		*out.ActiveDeadlineSeconds = *in.ActiveDeadlineSeconds
+	}
+	if in.JobTemplate != nil {
+		out.JobTemplate = in.JobTemplate.DeepCopy()
+	}
+	out.Ownership = in.Ownership.DeepCopy()
+	out.PostPopulate = in.PostPopulate.DeepCopy()
+	if in.Cache != nil {
+		out.Cache = new(Cache)
+		*out.Cache = *in.Cache
+	}
+}
+
+// DeepCopy returns a new copy of the Hook
+func (in *Hook) DeepCopy() *Hook {
+	if in == nil {
+		return nil
+	}
+	out := new(Hook)
+	*out = *in
+	if in.Command != nil {
+		out.Command = append([]string{}, in.Command...)
+	}
+	if in.Args != nil {
+		out.Args = append([]string{}, in.Args...)
+	}
+	return out
+}
+
+// DeepCopy returns a new copy of the Ownership
+func (in *Ownership) DeepCopy() *Ownership {
+	if in == nil {
+		return nil
+	}
+	out := new(Ownership)
+	*out = *in
+	if in.UID != nil {
+		out.UID = new(int64)
+		*out.UID = *in.UID
+	}
+	if in.GID != nil {
+		out.GID = new(int64)
+		*out.GID = *in.GID
+	}
+	return out
+}
+
+// DeepCopy returns a new copy of the JobTemplate
+func (in *JobTemplate) DeepCopy() *JobTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(JobTemplate)
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.NodeSelector != nil {
+		out.NodeSelector = make(map[string]string, len(in.NodeSelector))
+		for k, v := range in.NodeSelector {
+			out.NodeSelector[k] = v
+		}
+	}
+	out.Affinity = in.Affinity.DeepCopy()
+	if in.Tolerations != nil {
+		out.Tolerations = make([]core_v1.Toleration, len(in.Tolerations))
+		for i := range in.Tolerations {
+			in.Tolerations[i].DeepCopyInto(&out.Tolerations[i])
+		}
+	}
+	if in.RunAsUser != nil {
+		out.RunAsUser = new(int64)
+		*out.RunAsUser = *in.RunAsUser
+	}
+	if in.FSGroup != nil {
+		out.FSGroup = new(int64)
+		*out.FSGroup = *in.FSGroup
+	}
+	if in.ImagePullSecrets != nil {
+		out.ImagePullSecrets = make([]core_v1.LocalObjectReference, len(in.ImagePullSecrets))
+		copy(out.ImagePullSecrets, in.ImagePullSecrets)
+	}
+	return out
+}
+
+// DeepCopyInto copies the source, including whichever member of the union is set, into out
+func (in *PopulatorSource) DeepCopyInto(out *PopulatorSource) {
+	*out = *in
+	if in.Git != nil {
+		out.Git = new(GitSource)
+		*out.Git = *in.Git
+	}
+	if in.S3 != nil {
+		out.S3 = new(S3Source)
+		*out.S3 = *in.S3
+	}
+	out.Parameters = copyParameters(in.Parameters)
+}
+
+// copyParameters returns a copy of a source's Parameters, nil stays nil
+func copyParameters(in map[string]string) map[string]string {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+// DeepCopy returns a new copy of the Populator
+func (in *Populator) DeepCopy() *Populator {
+	if in == nil {
+		return nil
+	}
+	out := new(Populator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject returns a generically typed copy of an object
+func (in *Populator) DeepCopyObject() runtime.Object {
+	out := Populator{}
+	in.DeepCopyInto(&out)
+
+	return &out
+}
+
+// DeepCopyObject returns a generically typed copy of an object
+func (in *PopulatorList) DeepCopyObject() runtime.Object {
+	out := PopulatorList{}
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+
+	if in.Items != nil {
+		out.Items = make([]Populator, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+
+	return &out
+}
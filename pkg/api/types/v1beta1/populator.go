@@ -0,0 +1,133 @@
+package v1beta1
+
+import (
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GitSource describes a git repository to clone, at most one of Branch and Tag should be set
+type GitSource struct {
+	Repo   string `json:"repo"` // Full URL of the repo (https or git protocol)
+	Branch string `json:"branch,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+}
+
+// S3Source describes a bucket in an S3 (or S3 compatible) object store to copy from
+type S3Source struct {
+	Bucket   string `json:"bucket"`
+	Prefix   string `json:"prefix,omitempty"`   // Only objects under this key prefix are copied
+	Endpoint string `json:"endpoint,omitempty"` // Leave empty for AWS, set for S3 compatible stores
+	Region   string `json:"region,omitempty"`
+}
+
+// PopulatorSource is a union of the external data sources we know about, exactly one of Git, S3 and SourceType
+// must be set and which one it is determines the type of populator we run.  SourceType names a type registered with
+// the controller that doesn't have a block of its own, it's configured through Parameters
+type PopulatorSource struct {
+	Git        *GitSource        `json:"git,omitempty"`
+	S3         *S3Source         `json:"s3,omitempty"`
+	SourceType string            `json:"type,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	Subpath    string            `json:"subpath,omitempty"` // Directory under the mountpoint the data is written to
+	Image      string            `json:"image,omitempty"`   // Defaults to the built in image for the source type
+}
+
+// PopulatorSpec describes where the data comes from and where in the PVC it's written.  At most one of Source
+// and Sources may be set, Sources are populated one after the other in the order they're listed.  Include names
+// other Populators in the same namespace that are populated first, a Populator made up only of includes doesn't
+// need any sources of its own
+type PopulatorSpec struct {
+	Source                  *PopulatorSource  `json:"source,omitempty"`
+	Sources                 []PopulatorSource `json:"sources,omitempty"`
+	Include                 []string          `json:"include,omitempty"`
+	Mountpoint              string            `json:"mountpoint,omitempty"`
+	SecretRef               string            `json:"secretRef,omitempty"` // Secret in the Populator's namespace with source credentials
+	TTLSecondsAfterFinished *int32            `json:"ttlSecondsAfterFinished,omitempty"`
+	BackoffLimit            *int32            `json:"backoffLimit,omitempty"`          // Retries before the population is marked failed
+	ActiveDeadlineSeconds   *int64            `json:"activeDeadlineSeconds,omitempty"` // Overall time limit for the population
+	JobTemplate             *JobTemplate      `json:"jobTemplate,omitempty"`
+	Ownership               *Ownership        `json:"ownership,omitempty"`
+	PostPopulate            *Hook             `json:"postPopulate,omitempty"`
+	Cache                   *Cache            `json:"cache,omitempty"`
+	RefreshSchedule         string            `json:"refreshSchedule,omitempty"` // Cron schedule to refresh populated PVCs on
+	Overridable             []string          `json:"overridable,omitempty"`     // What PVCs may override with annotations
+}
+
+// Cache has the controller populate a golden volume once per Populator generation and clone it (mode "clone") or
+// restore a snapshot of it (mode "snapshot") for every PVC that uses the Populator
+type Cache struct {
+	Mode                    string `json:"mode"`
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+}
+
+// Hook is a user supplied step run in the mountpoint once all the sources have been written, if it fails the
+// population fails
+type Hook struct {
+	Image   string   `json:"image"`
+	Command []string `json:"command,omitempty"` // Defaults to the image's entrypoint
+	Args    []string `json:"args,omitempty"`
+}
+
+// Ownership sets who owns the populated data and its permissions, GID is applied as the pod's fsGroup, UID and
+// Mode are applied recursively once population has finished
+type Ownership struct {
+	UID  *int64 `json:"uid,omitempty"`
+	GID  *int64 `json:"gid,omitempty"`
+	Mode string `json:"mode,omitempty"` // chmod mode, octal ("0775") or symbolic ("g+rwX")
+}
+
+// JobTemplate holds the pod level settings that are merged into the populator job, anything left unset falls
+// back to the controller wide defaults and then to whatever the cluster does by default
+type JobTemplate struct {
+	Resources          core_v1.ResourceRequirements   `json:"resources,omitempty"`
+	NodeSelector       map[string]string              `json:"nodeSelector,omitempty"`
+	Affinity           *core_v1.Affinity              `json:"affinity,omitempty"`
+	Tolerations        []core_v1.Toleration           `json:"tolerations,omitempty"`
+	PriorityClassName  string                         `json:"priorityClassName,omitempty"`
+	ServiceAccountName string                         `json:"serviceAccountName,omitempty"`
+	RunAsUser          *int64                         `json:"runAsUser,omitempty"`
+	FSGroup            *int64                         `json:"fsGroup,omitempty"`
+	ImagePullSecrets   []core_v1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+}
+
+// Populator represents our CRD Object.  A populator is a DataSource used to pre-populate PVCs upon creation
+type Populator struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PopulatorSpec   `json:"spec"`
+	Status PopulatorStatus `json:"status,omitempty"`
+}
+
+// PopulatorStatus is written by the controller, Conditions holds the result of the checks it runs against the
+// Populator's sources (see ConditionSourceReachable)
+type PopulatorStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// PopulatorList provides a type of multiple Populators
+type PopulatorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Populator `json:"items"`
+}
+
+// Type returns the name of the source type that's set, or an empty string if there isn't exactly one
+func (s *PopulatorSource) Type() string {
+	var types []string
+	if s.Git != nil {
+		types = append(types, v1alpha1.TypeGit)
+	}
+	if s.S3 != nil {
+		types = append(types, v1alpha1.TypeS3)
+	}
+	if s.SourceType != "" {
+		types = append(types, s.SourceType)
+	}
+	if len(types) != 1 {
+		return ""
+	}
+	return types[0]
+}
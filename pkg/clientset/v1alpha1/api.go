@@ -10,6 +10,10 @@ import (
 
 type Interface interface {
 	Populators(namespace string) PopulatorInterface
+	PopulatorClasses() PopulatorClassInterface
+	PopulationRequests(namespace string) PopulationRequestInterface
+	PopulatorGrants(namespace string) PopulatorGrantInterface
+	ClusterPopulators() ClusterPopulatorInterface
 }
 
 type Client struct {
@@ -20,7 +24,7 @@ func NewForConfig(c *rest.Config) (*Client, error) {
 	config := *c
 	config.ContentConfig.GroupVersion = &schema.GroupVersion{Group: v1alpha1.GroupName, Version: v1alpha1.GroupVersion}
 	config.APIPath = "/apis"
-	config.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: scheme.Codecs}
+	config.NegotiatedSerializer = serializer.WithoutConversionCodecFactory{CodecFactory: scheme.Codecs}
 	config.UserAgent = rest.DefaultKubernetesUserAgent()
 
 	client, err := rest.RESTClientFor(&config)
@@ -37,3 +41,31 @@ func (c *Client) Populators(namespace string) PopulatorInterface {
 		ns:         namespace,
 	}
 }
+
+// PopulatorClasses are cluster scoped so there's no namespace to pass
+func (c *Client) PopulatorClasses() PopulatorClassInterface {
+	return &populatorClassClient{
+		restClient: c.restClient,
+	}
+}
+
+func (c *Client) PopulationRequests(namespace string) PopulationRequestInterface {
+	return &populationRequestClient{
+		restClient: c.restClient,
+		ns:         namespace,
+	}
+}
+
+func (c *Client) PopulatorGrants(namespace string) PopulatorGrantInterface {
+	return &populatorGrantClient{
+		restClient: c.restClient,
+		ns:         namespace,
+	}
+}
+
+// ClusterPopulators are cluster scoped like PopulatorClasses
+func (c *Client) ClusterPopulators() ClusterPopulatorInterface {
+	return &clusterPopulatorClient{
+		restClient: c.restClient,
+	}
+}
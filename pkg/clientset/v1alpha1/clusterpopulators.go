@@ -0,0 +1,85 @@
+package v1alpha1
+
+import (
+	"context"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+type ClusterPopulatorInterface interface {
+	List(ctx context.Context, opts metav1.ListOptions) (*v1alpha1.ClusterPopulatorList, error)
+	Get(ctx context.Context, name string, options metav1.GetOptions) (*v1alpha1.ClusterPopulator, error)
+	Create(context.Context, *v1alpha1.ClusterPopulator) (*v1alpha1.ClusterPopulator, error)
+	UpdateStatus(context.Context, *v1alpha1.ClusterPopulator) (*v1alpha1.ClusterPopulator, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+}
+
+type clusterPopulatorClient struct {
+	restClient rest.Interface
+}
+
+func (c *clusterPopulatorClient) List(ctx context.Context, opts metav1.ListOptions) (*v1alpha1.ClusterPopulatorList, error) {
+	result := v1alpha1.ClusterPopulatorList{}
+	err := c.restClient.
+		Get().
+		Resource("clusterpopulators").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *clusterPopulatorClient) Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1alpha1.ClusterPopulator, error) {
+	result := v1alpha1.ClusterPopulator{}
+	err := c.restClient.
+		Get().
+		Resource("clusterpopulators").
+		Name(name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *clusterPopulatorClient) Create(ctx context.Context, populator *v1alpha1.ClusterPopulator) (*v1alpha1.ClusterPopulator, error) {
+	result := v1alpha1.ClusterPopulator{}
+	err := c.restClient.
+		Post().
+		Resource("clusterpopulators").
+		Body(populator).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+// UpdateStatus writes the ClusterPopulator's status through the status subresource, anything changed in the spec
+// is ignored
+func (c *clusterPopulatorClient) UpdateStatus(ctx context.Context, populator *v1alpha1.ClusterPopulator) (*v1alpha1.ClusterPopulator, error) {
+	result := v1alpha1.ClusterPopulator{}
+	err := c.restClient.
+		Put().
+		Resource("clusterpopulators").
+		Name(populator.Name).
+		SubResource("status").
+		Body(populator).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *clusterPopulatorClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.restClient.
+		Get().
+		Resource("clusterpopulators").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch(ctx)
+}
@@ -0,0 +1,90 @@
+package v1alpha1
+
+import (
+	"context"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+type PopulationRequestInterface interface {
+	List(ctx context.Context, opts metav1.ListOptions) (*v1alpha1.PopulationRequestList, error)
+	Get(ctx context.Context, name string, options metav1.GetOptions) (*v1alpha1.PopulationRequest, error)
+	Create(context.Context, *v1alpha1.PopulationRequest) (*v1alpha1.PopulationRequest, error)
+	UpdateStatus(context.Context, *v1alpha1.PopulationRequest) (*v1alpha1.PopulationRequest, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+}
+
+type populationRequestClient struct {
+	restClient rest.Interface
+	ns         string
+}
+
+func (c *populationRequestClient) List(ctx context.Context, opts metav1.ListOptions) (*v1alpha1.PopulationRequestList, error) {
+	result := v1alpha1.PopulationRequestList{}
+	err := c.restClient.
+		Get().
+		Namespace(c.ns).
+		Resource("populationrequests").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *populationRequestClient) Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1alpha1.PopulationRequest, error) {
+	result := v1alpha1.PopulationRequest{}
+	err := c.restClient.
+		Get().
+		Namespace(c.ns).
+		Resource("populationrequests").
+		Name(name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *populationRequestClient) Create(ctx context.Context, request *v1alpha1.PopulationRequest) (*v1alpha1.PopulationRequest, error) {
+	result := v1alpha1.PopulationRequest{}
+	err := c.restClient.
+		Post().
+		Namespace(c.ns).
+		Resource("populationrequests").
+		Body(request).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+// UpdateStatus writes the request's status through the status subresource, the spec can't be changed
+func (c *populationRequestClient) UpdateStatus(ctx context.Context, request *v1alpha1.PopulationRequest) (*v1alpha1.PopulationRequest, error) {
+	result := v1alpha1.PopulationRequest{}
+	err := c.restClient.
+		Put().
+		Namespace(c.ns).
+		Resource("populationrequests").
+		Name(request.Name).
+		SubResource("status").
+		Body(request).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *populationRequestClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.restClient.
+		Get().
+		Namespace(c.ns).
+		Resource("populationrequests").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch(ctx)
+}
@@ -0,0 +1,67 @@
+package v1alpha1
+
+import (
+	"context"
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+type PopulatorClassInterface interface {
+	List(ctx context.Context, opts metav1.ListOptions) (*v1alpha1.PopulatorClassList, error)
+	Get(ctx context.Context, name string, options metav1.GetOptions) (*v1alpha1.PopulatorClass, error)
+	Create(context.Context, *v1alpha1.PopulatorClass) (*v1alpha1.PopulatorClass, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+}
+
+type populatorClassClient struct {
+	restClient rest.Interface
+}
+
+func (c *populatorClassClient) List(ctx context.Context, opts metav1.ListOptions) (*v1alpha1.PopulatorClassList, error) {
+	result := v1alpha1.PopulatorClassList{}
+	err := c.restClient.
+		Get().
+		Resource("populatorclasses").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do(context.TODO()).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *populatorClassClient) Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1alpha1.PopulatorClass, error) {
+	result := v1alpha1.PopulatorClass{}
+	err := c.restClient.
+		Get().
+		Resource("populatorclasses").
+		Name(name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do(context.TODO()).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *populatorClassClient) Create(ctx context.Context, class *v1alpha1.PopulatorClass) (*v1alpha1.PopulatorClass, error) {
+	result := v1alpha1.PopulatorClass{}
+	err := c.restClient.
+		Post().
+		Resource("populatorclasses").
+		Body(class).
+		Do(context.TODO()).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *populatorClassClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.restClient.
+		Get().
+		Resource("populatorclasses").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch(context.TODO())
+}
@@ -0,0 +1,73 @@
+package v1alpha1
+
+import (
+	"context"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+type PopulatorGrantInterface interface {
+	List(ctx context.Context, opts metav1.ListOptions) (*v1alpha1.PopulatorGrantList, error)
+	Get(ctx context.Context, name string, options metav1.GetOptions) (*v1alpha1.PopulatorGrant, error)
+	Create(context.Context, *v1alpha1.PopulatorGrant) (*v1alpha1.PopulatorGrant, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+}
+
+type populatorGrantClient struct {
+	restClient rest.Interface
+	ns         string
+}
+
+func (c *populatorGrantClient) List(ctx context.Context, opts metav1.ListOptions) (*v1alpha1.PopulatorGrantList, error) {
+	result := v1alpha1.PopulatorGrantList{}
+	err := c.restClient.
+		Get().
+		Namespace(c.ns).
+		Resource("populatorgrants").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *populatorGrantClient) Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1alpha1.PopulatorGrant, error) {
+	result := v1alpha1.PopulatorGrant{}
+	err := c.restClient.
+		Get().
+		Namespace(c.ns).
+		Resource("populatorgrants").
+		Name(name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *populatorGrantClient) Create(ctx context.Context, grant *v1alpha1.PopulatorGrant) (*v1alpha1.PopulatorGrant, error) {
+	result := v1alpha1.PopulatorGrant{}
+	err := c.restClient.
+		Post().
+		Namespace(c.ns).
+		Resource("populatorgrants").
+		Body(grant).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *populatorGrantClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.restClient.
+		Get().
+		Namespace(c.ns).
+		Resource("populatorgrants").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch(ctx)
+}
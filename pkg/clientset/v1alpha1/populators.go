@@ -1,6 +1,8 @@
 package v1alpha1
 
 import (
+	"context"
+
 	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
@@ -9,10 +11,11 @@ import (
 )
 
 type PopulatorInterface interface {
-	List(opts metav1.ListOptions) (*v1alpha1.PopulatorList, error)
-	Get(name string, options metav1.GetOptions) (*v1alpha1.Populator, error)
-	Create(*v1alpha1.Populator) (*v1alpha1.Populator, error)
-	Watch(opts metav1.ListOptions) (watch.Interface, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1alpha1.PopulatorList, error)
+	Get(ctx context.Context, name string, options metav1.GetOptions) (*v1alpha1.Populator, error)
+	Create(context.Context, *v1alpha1.Populator) (*v1alpha1.Populator, error)
+	UpdateStatus(context.Context, *v1alpha1.Populator) (*v1alpha1.Populator, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
 	// ...
 }
 
@@ -21,20 +24,20 @@ type populatorClient struct {
 	ns         string
 }
 
-func (c *populatorClient) List(opts metav1.ListOptions) (*v1alpha1.PopulatorList, error) {
+func (c *populatorClient) List(ctx context.Context, opts metav1.ListOptions) (*v1alpha1.PopulatorList, error) {
 	result := v1alpha1.PopulatorList{}
 	err := c.restClient.
 		Get().
 		Namespace(c.ns).
 		Resource("populators").
 		VersionedParams(&opts, scheme.ParameterCodec).
-		Do().
+		Do(ctx).
 		Into(&result)
 
 	return &result, err
 }
 
-func (c *populatorClient) Get(name string, opts metav1.GetOptions) (*v1alpha1.Populator, error) {
+func (c *populatorClient) Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1alpha1.Populator, error) {
 	result := v1alpha1.Populator{}
 	err := c.restClient.
 		Get().
@@ -42,31 +45,48 @@ func (c *populatorClient) Get(name string, opts metav1.GetOptions) (*v1alpha1.Po
 		Resource("populators").
 		Name(name).
 		VersionedParams(&opts, scheme.ParameterCodec).
-		Do().
+		Do(ctx).
 		Into(&result)
 
 	return &result, err
 }
 
-func (c *populatorClient) Create(project *v1alpha1.Populator) (*v1alpha1.Populator, error) {
+func (c *populatorClient) Create(ctx context.Context, project *v1alpha1.Populator) (*v1alpha1.Populator, error) {
 	result := v1alpha1.Populator{}
 	err := c.restClient.
 		Post().
 		Namespace(c.ns).
 		Resource("populators").
 		Body(project).
-		Do().
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+// UpdateStatus writes the Populator's status through the status subresource, anything changed in the spec is
+// ignored
+func (c *populatorClient) UpdateStatus(ctx context.Context, populator *v1alpha1.Populator) (*v1alpha1.Populator, error) {
+	result := v1alpha1.Populator{}
+	err := c.restClient.
+		Put().
+		Namespace(c.ns).
+		Resource("populators").
+		Name(populator.Name).
+		SubResource("status").
+		Body(populator).
+		Do(ctx).
 		Into(&result)
 
 	return &result, err
 }
 
-func (c *populatorClient) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+func (c *populatorClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
 	opts.Watch = true
 	return c.restClient.
 		Get().
 		Namespace(c.ns).
 		Resource("populators").
 		VersionedParams(&opts, scheme.ParameterCodec).
-		Watch()
+		Watch(ctx)
 }
@@ -0,0 +1,39 @@
+package v1beta1
+
+import (
+	"github.com/j-griffith/populator/pkg/api/types/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+type Interface interface {
+	Populators(namespace string) PopulatorInterface
+}
+
+type Client struct {
+	restClient rest.Interface
+}
+
+func NewForConfig(c *rest.Config) (*Client, error) {
+	config := *c
+	config.ContentConfig.GroupVersion = &schema.GroupVersion{Group: v1beta1.GroupName, Version: v1beta1.GroupVersion}
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = serializer.WithoutConversionCodecFactory{CodecFactory: scheme.Codecs}
+	config.UserAgent = rest.DefaultKubernetesUserAgent()
+
+	client, err := rest.RESTClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{restClient: client}, nil
+}
+
+func (c *Client) Populators(namespace string) PopulatorInterface {
+	return &populatorClient{
+		restClient: c.restClient,
+		ns:         namespace,
+	}
+}
@@ -0,0 +1,92 @@
+package v1beta1
+
+import (
+	"context"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+type PopulatorInterface interface {
+	List(ctx context.Context, opts metav1.ListOptions) (*v1beta1.PopulatorList, error)
+	Get(ctx context.Context, name string, options metav1.GetOptions) (*v1beta1.Populator, error)
+	Create(context.Context, *v1beta1.Populator) (*v1beta1.Populator, error)
+	UpdateStatus(context.Context, *v1beta1.Populator) (*v1beta1.Populator, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	// ...
+}
+
+type populatorClient struct {
+	restClient rest.Interface
+	ns         string
+}
+
+func (c *populatorClient) List(ctx context.Context, opts metav1.ListOptions) (*v1beta1.PopulatorList, error) {
+	result := v1beta1.PopulatorList{}
+	err := c.restClient.
+		Get().
+		Namespace(c.ns).
+		Resource("populators").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *populatorClient) Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1beta1.Populator, error) {
+	result := v1beta1.Populator{}
+	err := c.restClient.
+		Get().
+		Namespace(c.ns).
+		Resource("populators").
+		Name(name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *populatorClient) Create(ctx context.Context, project *v1beta1.Populator) (*v1beta1.Populator, error) {
+	result := v1beta1.Populator{}
+	err := c.restClient.
+		Post().
+		Namespace(c.ns).
+		Resource("populators").
+		Body(project).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+// UpdateStatus writes the Populator's status through the status subresource, anything changed in the spec is
+// ignored
+func (c *populatorClient) UpdateStatus(ctx context.Context, populator *v1beta1.Populator) (*v1beta1.Populator, error) {
+	result := v1beta1.Populator{}
+	err := c.restClient.
+		Put().
+		Namespace(c.ns).
+		Resource("populators").
+		Name(populator.Name).
+		SubResource("status").
+		Body(populator).
+		Do(ctx).
+		Into(&result)
+
+	return &result, err
+}
+
+func (c *populatorClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.restClient.
+		Get().
+		Namespace(c.ns).
+		Resource("populators").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch(ctx)
+}
@@ -0,0 +1,414 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	core_v1 "k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// A cached Populator is populated once per generation into a golden PVC, every PVC using it then gets a copy.  We
+// can't clone into a PVC that already exists, so the copy is made into a prime PVC (a clone of the golden PVC, or
+// a restore of a snapshot of it) and once that's bound its PV is handed over to the PVC that asked for it, the
+// same way the volume populator library does it.  Anything we can't copy falls back to a populator job
+
+var volumeSnapshotResource = schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshots"}
+
+// annSelectedNode is set on a PVC by the scheduler once a pod using it has been placed, a WaitForFirstConsumer
+// class won't provision a volume for a PVC without it
+const annSelectedNode = "volume.kubernetes.io/selected-node"
+
+// goldenName is the golden PVC (and snapshot) in pvc's namespace for the Populator's current generation, the
+// Populator's namespace is included if it's somewhere else so Populators with the same name don't collide
+func goldenName(pvc *core_v1.PersistentVolumeClaim, pop *v1alpha1.Populator) string {
+	if pop.IsCluster() {
+		return fmt.Sprintf("cluster-%s-golden-%d", pop.Name, pop.Generation)
+	}
+	if pop.Namespace != pvc.Namespace {
+		return fmt.Sprintf("%s-%s-golden-%d", pop.Namespace, pop.Name, pop.Generation)
+	}
+	return fmt.Sprintf("%s-golden-%d", pop.Name, pop.Generation)
+}
+
+// goldenPrefix is goldenName without the generation, every generation's golden PVC starts with it
+func goldenPrefix(pvc *core_v1.PersistentVolumeClaim, pop *v1alpha1.Populator) string {
+	name := goldenName(pvc, pop)
+	return name[:strings.LastIndex(name, "-")+1]
+}
+
+// primeName is the PVC the golden volume is copied into on behalf of pvc
+func primeName(pvc *core_v1.PersistentVolumeClaim) string {
+	return "populator-prime-" + string(pvc.UID)
+}
+
+// populateFromCache sets up a copy of the Populator's golden volume for pvc, it returns false if that can't be
+// done and the caller should run a job instead
+func (p *PopulatorHandler) populateFromCache(pvc *core_v1.PersistentVolumeClaim, pop *v1alpha1.Populator) bool {
+	if pvc.Spec.VolumeName != "" {
+		log.Printf("PVC %s is already bound, populating it with a job instead of from the cache", pvc.Name)
+		return false
+	}
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		log.Printf("PVC %s has no storage class, populating it with a job instead of from the cache", pvc.Name)
+		return false
+	}
+	sc, err := p.checkCloneSupport(*pvc.Spec.StorageClassName)
+	if err != nil {
+		log.Printf("populating PVC %s with a job instead of from the cache: %v", pvc.Name, err)
+		p.recordEvent(pvc, core_v1.EventTypeNormal, "CacheUnavailable", "populating with a job: %v", err)
+		return false
+	}
+	// the prime PVC has to be provisioned on the node the PVC's pod was scheduled to, until there is one the job's
+	// pod is what gets the PVC scheduled
+	waitForConsumer := sc.VolumeBindingMode != nil && *sc.VolumeBindingMode == storage.VolumeBindingWaitForFirstConsumer
+	if waitForConsumer && pvc.Annotations[annSelectedNode] == "" {
+		log.Printf("PVC %s is waiting for a consumer to be scheduled, populating it with a job instead of from the cache", pvc.Name)
+		return false
+	}
+
+	golden, err := p.ensureGolden(pvc, pop)
+	if err != nil {
+		log.Printf("unable to set up golden PVC for Populator %s: %v", pop.Name, err)
+		p.recordEvent(pvc, core_v1.EventTypeWarning, "CacheUnavailable", "populating with a job: %v", err)
+		return false
+	}
+	// clones have to stay in the storage class and can grow but not shrink
+	if golden.Spec.StorageClassName == nil || *golden.Spec.StorageClassName != *pvc.Spec.StorageClassName {
+		log.Printf("golden PVC %s is in a different storage class to PVC %s, populating it with a job", golden.Name, pvc.Name)
+		return false
+	}
+	if pvc.Spec.Resources.Requests.Storage().Cmp(*golden.Spec.Resources.Requests.Storage()) < 0 {
+		log.Printf("PVC %s is smaller than golden PVC %s, populating it with a job", pvc.Name, golden.Name)
+		return false
+	}
+
+	switch populationStatus(golden) {
+	case v1alpha1.PopulationSucceeded:
+		// this generation is good to copy from, the ones before it can go once nothing's using them
+		p.deleteOldGoldens(pvc, pop, golden.Name)
+	case v1alpha1.PopulationFailed:
+		log.Printf("golden PVC %s failed to populate, populating PVC %s with a job", golden.Name, pvc.Name)
+		return false
+	default:
+		// releaseWaiting picks this up once the golden PVC is done
+		if err := patchLabels(p.KubeClient, pvc, map[string]string{v1alpha1.LabelWaitingFor: golden.Name}); err != nil {
+			log.Printf("unable to label PVC %s: %v", pvc.Name, err)
+			return false
+		}
+		if err := setPopulationStatus(p.KubeClient, pvc, v1alpha1.PopulationPending, "", "waiting for golden PVC "+golden.Name); err != nil {
+			log.Printf("unable to record population status on PVC %s: %v", pvc.Name, err)
+		}
+		return true
+	}
+
+	dataSource := &core_v1.TypedLocalObjectReference{Kind: "PersistentVolumeClaim", Name: golden.Name}
+	if pop.Spec.Cache.Mode == v1alpha1.CacheModeSnapshot {
+		if err := p.ensureSnapshot(golden, pop); err != nil {
+			log.Printf("unable to snapshot golden PVC %s: %v", golden.Name, err)
+			p.recordEvent(pvc, core_v1.EventTypeWarning, "CacheUnavailable", "populating with a job: %v", err)
+			return false
+		}
+		group := volumeSnapshotResource.Group
+		dataSource = &core_v1.TypedLocalObjectReference{APIGroup: &group, Kind: "VolumeSnapshot", Name: golden.Name}
+	}
+
+	prime := &core_v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        primeName(pvc),
+			Namespace:   pvc.Namespace,
+			Labels:      map[string]string{v1alpha1.LabelPrimeFor: pvc.Name},
+			Annotations: map[string]string{v1alpha1.AnnPopulatedFrom: golden.Name},
+		},
+		Spec: core_v1.PersistentVolumeClaimSpec{
+			AccessModes:      pvc.Spec.AccessModes,
+			Resources:        *pvc.Spec.Resources.DeepCopy(),
+			StorageClassName: pvc.Spec.StorageClassName,
+			VolumeMode:       pvc.Spec.VolumeMode,
+			DataSource:       dataSource,
+		},
+	}
+	if node := pvc.Annotations[annSelectedNode]; node != "" && waitForConsumer {
+		prime.Annotations[annSelectedNode] = node
+	}
+	if _, err := p.KubeClient.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(context.TODO(), prime, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		log.Printf("unable to create prime PVC for %s: %v", pvc.Name, err)
+		p.recordEvent(pvc, core_v1.EventTypeWarning, "CacheUnavailable", "populating with a job: %v", err)
+		return false
+	}
+	p.recordEvent(pvc, core_v1.EventTypeNormal, "PopulatingFromCache", "copying golden PVC %s (%s)", golden.Name, pop.Spec.Cache.Mode)
+	if err := setPopulationStatus(p.KubeClient, pvc, v1alpha1.PopulationRunning, "", "copying golden PVC "+golden.Name); err != nil {
+		log.Printf("unable to record population status on PVC %s: %v", pvc.Name, err)
+	}
+	return true
+}
+
+// checkCloneSupport makes sure the storage class is provisioned by a CSI driver, nothing else can clone or
+// restore snapshots.  It returns the storage class
+func (p *PopulatorHandler) checkCloneSupport(storageClassName string) (*storage.StorageClass, error) {
+	sc, err := p.KubeClient.StorageV1().StorageClasses().Get(context.TODO(), storageClassName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.KubeClient.StorageV1().CSIDrivers().Get(context.TODO(), sc.Provisioner, metav1.GetOptions{}); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("storage class %s isn't provisioned by a CSI driver", storageClassName)
+		}
+		return nil, err
+	}
+	return sc, nil
+}
+
+// ensureGolden returns the golden PVC for the Populator's current generation, creating it if it doesn't exist.
+// It's a PVC like any other using the Populator, so it's populated by a job in the usual way
+func (p *PopulatorHandler) ensureGolden(pvc *core_v1.PersistentVolumeClaim, pop *v1alpha1.Populator) (*core_v1.PersistentVolumeClaim, error) {
+	pvcs := p.KubeClient.CoreV1().PersistentVolumeClaims(pvc.Namespace)
+	golden, err := pvcs.Get(context.TODO(), goldenName(pvc, pop), metav1.GetOptions{})
+	if err == nil || !errors.IsNotFound(err) {
+		return golden, err
+	}
+	golden = &core_v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            goldenName(pvc, pop),
+			Namespace:       pvc.Namespace,
+			Labels:          map[string]string{v1alpha1.LabelGolden: pop.Name},
+			OwnerReferences: populatorOwner(pvc, pop),
+		},
+		Spec: core_v1.PersistentVolumeClaimSpec{
+			AccessModes:      pvc.Spec.AccessModes,
+			Resources:        *pvc.Spec.Resources.DeepCopy(),
+			StorageClassName: pvc.Spec.StorageClassName,
+			VolumeMode:       pvc.Spec.VolumeMode,
+			DataSource:       pvc.Spec.DataSource.DeepCopy(),
+		},
+	}
+	if !pop.IsCluster() && pop.Namespace != pvc.Namespace {
+		golden.Annotations = map[string]string{v1alpha1.AnnPopulatorNamespace: pop.Namespace}
+	}
+	log.Printf("creating golden PVC %s for Populator %s", golden.Name, pop.Name)
+	created, err := pvcs.Create(context.TODO(), golden, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		return pvcs.Get(context.TODO(), golden.Name, metav1.GetOptions{})
+	}
+	return created, err
+}
+
+// populatorOwner makes the Populator the owner of its golden PVCs so they're cleaned up along with it, owners
+// have to be in the same namespace (or cluster scoped) so a Populator used from another namespace can't be
+func populatorOwner(pvc *core_v1.PersistentVolumeClaim, pop *v1alpha1.Populator) []metav1.OwnerReference {
+	kind := "Populator"
+	if pop.IsCluster() {
+		kind = v1alpha1.ClusterKind
+	} else if pop.Namespace != pvc.Namespace {
+		return nil
+	}
+	return []metav1.OwnerReference{{
+		APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		Kind:       kind,
+		Name:       pop.Name,
+		UID:        pop.UID,
+	}}
+}
+
+// deleteOldGoldens deletes the golden PVCs of the Populator's earlier generations in pvc's namespace, along with
+// their snapshots.  One that a PVC is still waiting for or being copied from is left for the next time round
+func (p *PopulatorHandler) deleteOldGoldens(pvc *core_v1.PersistentVolumeClaim, pop *v1alpha1.Populator, current string) {
+	pvcs := p.KubeClient.CoreV1().PersistentVolumeClaims(pvc.Namespace)
+	goldens, err := pvcs.List(context.TODO(), metav1.ListOptions{LabelSelector: v1alpha1.LabelGolden + "=" + pop.Name})
+	if err != nil {
+		log.Printf("unable to list golden PVCs for Populator %s: %v", pop.Name, err)
+		return
+	}
+	prefix := goldenPrefix(pvc, pop)
+	for i := range goldens.Items {
+		old := &goldens.Items[i]
+		// Populators with the same name in other namespaces have golden PVCs with the same label
+		if old.Name == current || !strings.HasPrefix(old.Name, prefix) || strings.Contains(old.Name[len(prefix):], "-") {
+			continue
+		}
+		inUse, err := p.goldenInUse(old)
+		if err != nil {
+			log.Printf("unable to check whether golden PVC %s is in use: %v", old.Name, err)
+			continue
+		}
+		if inUse {
+			continue
+		}
+		log.Printf("deleting golden PVC %s, Populator %s has moved on to %s", old.Name, pop.Name, current)
+		if p.DynamicClient != nil {
+			err := p.DynamicClient.Resource(volumeSnapshotResource).Namespace(old.Namespace).Delete(context.TODO(), old.Name, metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				log.Printf("unable to delete VolumeSnapshot %s: %v", old.Name, err)
+				continue
+			}
+		}
+		if err := pvcs.Delete(context.TODO(), old.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			log.Printf("unable to delete golden PVC %s: %v", old.Name, err)
+		}
+	}
+}
+
+// goldenInUse returns true if a PVC is waiting for the golden PVC or a prime PVC is still being copied from it
+func (p *PopulatorHandler) goldenInUse(golden *core_v1.PersistentVolumeClaim) (bool, error) {
+	pvcs := p.KubeClient.CoreV1().PersistentVolumeClaims(golden.Namespace)
+	waiting, err := pvcs.List(context.TODO(), metav1.ListOptions{LabelSelector: v1alpha1.LabelWaitingFor + "=" + golden.Name})
+	if err != nil {
+		return false, err
+	}
+	if len(waiting.Items) > 0 {
+		return true, nil
+	}
+	primes, err := pvcs.List(context.TODO(), metav1.ListOptions{LabelSelector: v1alpha1.LabelPrimeFor})
+	if err != nil {
+		return false, err
+	}
+	for i := range primes.Items {
+		if primes.Items[i].Annotations[v1alpha1.AnnPopulatedFrom] == golden.Name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ensureSnapshot creates a VolumeSnapshot of the golden PVC if there isn't one already, PVCs restored from it
+// are held back by the provisioner until it's ready to use
+func (p *PopulatorHandler) ensureSnapshot(golden *core_v1.PersistentVolumeClaim, pop *v1alpha1.Populator) error {
+	if p.DynamicClient == nil {
+		return fmt.Errorf("no client for VolumeSnapshots")
+	}
+	snapshots := p.DynamicClient.Resource(volumeSnapshotResource).Namespace(golden.Namespace)
+	_, err := snapshots.Get(context.TODO(), golden.Name, metav1.GetOptions{})
+	if err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{"persistentVolumeClaimName": golden.Name},
+	}
+	if pop.Spec.Cache.VolumeSnapshotClassName != "" {
+		spec["volumeSnapshotClassName"] = pop.Spec.Cache.VolumeSnapshotClassName
+	}
+	snapshot := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": volumeSnapshotResource.GroupVersion().String(),
+		"kind":       "VolumeSnapshot",
+		"metadata": map[string]interface{}{
+			"name":      golden.Name,
+			"namespace": golden.Namespace,
+			"labels":    map[string]interface{}{v1alpha1.LabelGolden: pop.Name},
+			// it goes when the golden PVC does
+			"ownerReferences": []interface{}{map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "PersistentVolumeClaim",
+				"name":       golden.Name,
+				"uid":        string(golden.UID),
+			}},
+		},
+		"spec": spec,
+	}}
+	log.Printf("creating VolumeSnapshot %s of golden PVC %s", golden.Name, golden.Name)
+	if _, err := snapshots.Create(context.TODO(), snapshot, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// releaseWaiting is called once a golden PVC has finished populating, every PVC waiting for it goes round again
+// and either gets a copy or, if the golden PVC failed, a job of its own
+func (p *PopulatorHandler) releaseWaiting(golden *core_v1.PersistentVolumeClaim) {
+	waiting, err := p.KubeClient.CoreV1().PersistentVolumeClaims(golden.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: v1alpha1.LabelWaitingFor + "=" + golden.Name,
+	})
+	if err != nil {
+		log.Printf("unable to list PVCs waiting for golden PVC %s: %v", golden.Name, err)
+		return
+	}
+	for i := range waiting.Items {
+		pvc := &waiting.Items[i]
+		// it's no longer waiting whatever happens next, and the label would keep the golden PVC from being
+		// cleaned up
+		if err := removeLabel(p.KubeClient, pvc, v1alpha1.LabelWaitingFor); err != nil {
+			log.Printf("unable to remove label %s from PVC %s: %v", v1alpha1.LabelWaitingFor, pvc.Name, err)
+		}
+		if populationStatus(pvc) == v1alpha1.PopulationPending {
+			p.ObjectCreated(pvc)
+		}
+	}
+}
+
+// syncPrime hands the prime PVC's volume over to the PVC it was made for once the copy is bound, and then
+// deletes the prime PVC
+func (p *PopulatorHandler) syncPrime(prime *core_v1.PersistentVolumeClaim, targetName string) {
+	if prime.Status.Phase != core_v1.ClaimBound {
+		// the provisioner is still copying
+		return
+	}
+	pvcs := p.KubeClient.CoreV1().PersistentVolumeClaims(prime.Namespace)
+	target, err := pvcs.Get(context.TODO(), targetName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		log.Printf("PVC %s went away, deleting prime PVC %s", targetName, prime.Name)
+		if err := pvcs.Delete(context.TODO(), prime.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			log.Printf("unable to delete prime PVC %s: %v", prime.Name, err)
+		}
+		return
+	} else if err != nil {
+		log.Printf("unable to fetch PVC %s for prime PVC %s: %v", targetName, prime.Name, err)
+		return
+	}
+	if target.Spec.VolumeName != "" && target.Spec.VolumeName != prime.Spec.VolumeName {
+		log.Printf("PVC %s was bound to %s while we were copying into %s, leaving it alone", target.Name, target.Spec.VolumeName, prime.Name)
+		return
+	}
+
+	// pointing the PV at the target is enough for the PV controller to bind them, the prime PVC no longer owns
+	// the PV so deleting it doesn't touch the data
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"claimRef": map[string]interface{}{
+				"apiVersion":      "v1",
+				"kind":            "PersistentVolumeClaim",
+				"namespace":       target.Namespace,
+				"name":            target.Name,
+				"uid":             string(target.UID),
+				"resourceVersion": nil,
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("unable to build claimRef patch: %v", err)
+		return
+	}
+	if _, err := p.KubeClient.CoreV1().PersistentVolumes().Patch(context.TODO(), prime.Spec.VolumeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		log.Printf("unable to hand PV %s over to PVC %s: %v", prime.Spec.VolumeName, target.Name, err)
+		return
+	}
+	if err := pvcs.Delete(context.TODO(), prime.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		log.Printf("unable to delete prime PVC %s: %v", prime.Name, err)
+	}
+
+	goldenName := prime.Annotations[v1alpha1.AnnPopulatedFrom]
+	annotations := map[string]string{v1alpha1.AnnPopulatedFrom: goldenName}
+	if golden, err := pvcs.Get(context.TODO(), goldenName, metav1.GetOptions{}); err == nil {
+		// the copy has the same content, and so the same manifest, as the golden volume
+		for _, ann := range []string{v1alpha1.AnnContentDigest, v1alpha1.AnnManifest} {
+			if v, ok := golden.Annotations[ann]; ok {
+				annotations[ann] = v
+			}
+		}
+	}
+	if err := patchAnnotations(p.KubeClient, target, annotations); err != nil {
+		log.Printf("unable to record golden PVC on PVC %s: %v", target.Name, err)
+	}
+	if err := setPopulationStatus(p.KubeClient, target, v1alpha1.PopulationSucceeded, "", ""); err != nil {
+		log.Printf("unable to record population status on PVC %s: %v", target.Name, err)
+		return
+	}
+	p.recordEvent(target, core_v1.EventTypeNormal, "PopulationSucceeded", "copied from golden PVC %s", goldenName)
+}
@@ -16,6 +16,28 @@ import (
 
 var myKubeClient kubernetes.Interface
 
+// defaultMaxRetries is how many times a key is retried when the Controller doesn't say otherwise
+const defaultMaxRetries = 5
+
+// RetryHandler is a Handler that can fail in ways worth another go, the Controller calls Sync for it in place of
+// ObjectCreated.  An error puts the key back on the queue with the queue's backoff until MaxRetries is used up,
+// lastAttempt tells the handler it won't get another go so it can record the failure for good
+type RetryHandler interface {
+	Handler
+	Sync(obj interface{}, lastAttempt bool) error
+}
+
+// WaitError is returned by a RetryHandler that can't do anything until something else has finished, the key is
+// looked at again after After without using up any of its retries
+type WaitError struct {
+	After  time.Duration
+	Reason string
+}
+
+func (e *WaitError) Error() string {
+	return e.Reason
+}
+
 // Controller struct defines how a controller should encapsulate
 // client connectivity, informing (list and watching)
 // queueing, and handling of resource changes
@@ -25,6 +47,8 @@ type Controller struct {
 	Informer           cache.SharedIndexInformer
 	Handler            Handler
 	PopulatorClientSet clientset.Interface
+	// MaxRetries is how many times a key a RetryHandler fails on is retried, defaultMaxRetries if it's 0
+	MaxRetries int
 }
 
 // Run is the main path of execution for the controller loop
@@ -97,11 +121,11 @@ func (c *Controller) processNextItem() bool {
 	//
 	// if there is an error in getting the key from the index
 	// then we want to retry this particular queue key a certain
-	// number of times (5 here) before we forget the queue key
+	// number of times (MaxRetries) before we forget the queue key
 	// and throw an error
 	item, exists, err := c.Informer.GetIndexer().GetByKey(keyRaw)
 	if err != nil {
-		if c.Queue.NumRequeues(key) < 5 {
+		if c.Queue.NumRequeues(key) < c.maxRetries() {
 			log.Printf("failed processing item with key %s, error: %v (attempting retries)", key, err)
 			c.Queue.AddRateLimited(key)
 		} else {
@@ -121,6 +145,19 @@ func (c *Controller) processNextItem() bool {
 		log.Printf("object delete detected: %s", keyRaw)
 		c.Handler.ObjectDeleted(item)
 		c.Queue.Forget(key)
+	} else if h, ok := c.Handler.(RetryHandler); ok {
+		log.Printf("object create detected: %s", keyRaw)
+		lastAttempt := c.Queue.NumRequeues(key) >= c.maxRetries()
+		err := h.Sync(item, lastAttempt)
+		if wait, ok := err.(*WaitError); ok {
+			log.Printf("item with key %s is waiting: %s", key, wait.Reason)
+			c.Queue.AddAfter(key, wait.After)
+		} else if err != nil && !lastAttempt {
+			log.Printf("failed processing item with key %s, error: %v (attempting retries)", key, err)
+			c.Queue.AddRateLimited(key)
+		} else {
+			c.Queue.Forget(key)
+		}
 	} else {
 		log.Printf("object create detected: %s", keyRaw)
 		c.Handler.ObjectCreated(item)
@@ -130,3 +167,10 @@ func (c *Controller) processNextItem() bool {
 	// keep the worker loop running by returning true
 	return true
 }
+
+func (c *Controller) maxRetries() int {
+	if c.MaxRetries > 0 {
+		return c.MaxRetries
+	}
+	return defaultMaxRetries
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/j-griffith/populator/pkg/populator"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DownloadCacheEvictor keeps the shared download cache under its size limit.  The controller can't see into the
+// cache PVC itself, so every Interval it runs a job that evicts the least recently used entries
+type DownloadCacheEvictor struct {
+	KubeClient kubernetes.Interface
+	Namespace  string
+	Claim      string
+	LimitBytes int64
+	Interval   time.Duration
+}
+
+// Run launches an eviction job every Interval until stopCh is closed
+func (e *DownloadCacheEvictor) Run(stopCh <-chan struct{}) {
+	log.Printf("evicting from download cache %s/%s every %v, limit %d bytes", e.Namespace, e.Claim, e.Interval, e.LimitBytes)
+	wait.Until(e.evict, e.Interval, stopCh)
+}
+
+func (e *DownloadCacheEvictor) evict() {
+	jobs, err := e.KubeClient.BatchV1().Jobs(e.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: "app=populator-cache-evict"})
+	if err != nil {
+		log.Printf("unable to list download cache eviction jobs: %v", err)
+		return
+	}
+	for i := range jobs.Items {
+		if !populator.JobFinished(&jobs.Items[i]) {
+			log.Printf("download cache eviction job %s is still running, skipping this round", jobs.Items[i].Name)
+			return
+		}
+	}
+	job := populator.BuildCacheEvictionJob(e.Claim, e.LimitBytes)
+	if _, err := populator.RunPopulatorJob(e.KubeClient, job, e.Namespace); err != nil {
+		log.Printf("unable to launch download cache eviction job: %v", err)
+	}
+}
@@ -1,13 +1,22 @@
 package controller
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
 	clientset "github.com/j-griffith/populator/pkg/clientset/v1alpha1"
 	"github.com/j-griffith/populator/pkg/populator"
+	batch "k8s.io/api/batch/v1"
 	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 )
 
 // Handler interface contains the methods that are required
@@ -18,10 +27,23 @@ type Handler interface {
 	ObjectUpdated(objOld, objNew interface{})
 }
 
+// jobWait is how long a refresh waiting for another job to finish with its PVC waits before looking again
+const jobWait = 30 * time.Second
+
 // PopulatorHandler is a sample implementation of Handler
 type PopulatorHandler struct {
 	KubeClient      kubernetes.Interface
 	PopulatorClient clientset.Interface
+	Recorder        record.EventRecorder
+	// JobTemplateNamespace and JobTemplateConfigMap optionally name a ConfigMap with the default job template,
+	// it's read for every job so changes take effect without restarting the controller
+	JobTemplateNamespace string
+	JobTemplateConfigMap string
+	// Preflight checks every source can be reached before launching the job, the result is recorded in the
+	// Populators' SourceReachable condition
+	Preflight bool
+	// DynamicClient is optional, it's used to create VolumeSnapshots for Populators cached in snapshot mode
+	DynamicClient dynamic.Interface
 }
 
 // Init handles any handler initialization
@@ -30,34 +52,146 @@ func (p *PopulatorHandler) Init() error {
 	return nil
 }
 
-// ObjectCreated is called when an object is created
+// ObjectCreated is called when an object is created, outside of a Controller nobody retries it so every attempt
+// is the last
 func (p *PopulatorHandler) ObjectCreated(obj interface{}) {
+	if err := p.Sync(obj, true); err != nil {
+		log.Printf("unable to handle PVC: %v", err)
+	}
+}
+
+// Sync does the work for ObjectCreated, the Controller retries the PVC when it returns an error.  Failures that
+// might clear up on their own (a source that can't be reached right now) only mark the population failed on the
+// last attempt
+func (p *PopulatorHandler) Sync(obj interface{}, lastAttempt bool) error {
 	log.Println("handle ObjectCreated event")
 	// assert the type to a PVC object to pull out relevant data
 	pvc := obj.(*core_v1.PersistentVolumeClaim)
 
+	// prime PVCs hold a copy of a golden volume for another PVC, they don't reference a Populator themselves
+	if target := pvc.Labels[v1alpha1.LabelPrimeFor]; target != "" {
+		p.syncPrime(pvc, target)
+		return nil
+	}
+
 	// If there's no DS specified just ignore it and move along (and don't vomit when you try and acess the field)
 	if pvc.Spec.DataSource == nil {
 		log.Printf("no DataSource entry for PVC %s, moving along", pvc.Name)
-		return
+		return nil
+	}
+	// Snapshots, clones and other people's populators aren't ours to handle
+	if !v1alpha1.IsPopulatorDataSource(pvc.Spec.DataSource) {
+		log.Printf("DataSource for PVC %s is a %s, not a Populator, moving along", pvc.Name, pvc.Spec.DataSource.Kind)
+		return nil
+	}
+	status := populationStatus(pvc)
+	refresh := ""
+	switch {
+	case pvc.Labels[v1alpha1.LabelGolden] != "" && (status == v1alpha1.PopulationSucceeded || status == v1alpha1.PopulationFailed):
+		p.releaseWaiting(pvc)
+		return nil
+	case status == v1alpha1.PopulationPending:
+		// waiting for a golden volume, see if it's ready
+	case refreshRequested(pvc) && (status == v1alpha1.PopulationSucceeded || status == v1alpha1.PopulationFailed):
+		refresh = pvc.Annotations[v1alpha1.AnnRefresh]
+	case status != "":
+		// We've already launched a job for this one, the JobHandler takes it from here
+		log.Printf("PVC %s population is %s, moving along", pvc.Name, status)
+		return nil
 	}
 
 	// TODO: throw in some error checking so we don't hit nil pointer type crashes if somebody didn't fill this out correctly
 	// Some of it we handle with the requirements in the CRD, others we can add webhooks, but for now living on the edge
-	pop, err := p.PopulatorClient.Populators("default").Get(pvc.Spec.DataSource.Name, metav1.GetOptions{})
+	// a ClusterPopulator comes back as a Populator, along with anything it includes
+	ns, get := populator.PopulatorNamespace(pvc), populator.DataSourceGetter(p.PopulatorClient, pvc)
+	pop, err := get(pvc.Spec.DataSource.Name)
 	if err != nil {
 		log.Printf("unable to fetch requested DataSource: %s, error: %v\n", pvc.Spec.DataSource.Name, err)
 		log.Printf("PV was created but will NOT be populated\n")
-		return
+		p.recordEvent(pvc, core_v1.EventTypeWarning, "PopulatorNotFound", "unable to fetch %s %s: %v", pvc.Spec.DataSource.Kind, pvc.Spec.DataSource.Name, err)
+		return nil
 	}
-	// CreateJobFromObjects creates the job spec and launches it
-	job, err := populator.CreateJobFromObjects(p.KubeClient, pvc, pop)
+	// Pull in anything the Populator includes, in the order it needs populating
+	pops, err := populator.ResolveIncludes(get, pop)
 	if err != nil {
-
+		log.Printf("unable to resolve includes for Populator %s: %v", pop.Name, err)
+		p.recordEvent(pvc, core_v1.EventTypeWarning, "PopulatorIncludeFailed", "%v", err)
+		return nil
+	}
+	if err := p.checkGrants(pvc, pops); err != nil {
+		log.Printf("PVC %s can't use Populator %s/%s: %v", pvc.Name, ns, pop.Name, err)
+		p.recordEvent(pvc, core_v1.EventTypeWarning, "PopulatorNotGranted", "%v", err)
+		if err := setPopulationStatus(p.KubeClient, pvc, v1alpha1.PopulationFailed, "", err.Error()); err != nil {
+			log.Printf("unable to record population status on PVC %s: %v", pvc.Name, err)
+		}
+		return nil
+	}
+	// a refresh rewrites a volume that's in use, it waits for any other job writing to it (a PopulationRequest's,
+	// say) to finish first
+	if refresh != "" {
+		job, err := pvcLocked(p.KubeClient, pvc)
+		if err != nil {
+			return fmt.Errorf("unable to list jobs for PVC %s: %v", pvc.Name, err)
+		}
+		if job != nil {
+			return &WaitError{After: jobWait, Reason: fmt.Sprintf("refresh of PVC %s is waiting for job %s to finish", pvc.Name, job.Name)}
+		}
+	}
+	if p.Preflight && (status == "" || refresh != "") {
+		if err := p.preflight(pvc, pops); err != nil {
+			if !lastAttempt {
+				return err
+			}
+			p.failPopulation(pvc, refresh, err.Error())
+			return err
+		}
+	}
+	// the golden PVC itself is populated by a job like any other, a refresh updates the PVC's own volume and a PVC
+	// overriding the Populator's sources (or rendering its templates) wants different data to the golden volume's
+	if pop.Spec.Cache != nil && pvc.Labels[v1alpha1.LabelGolden] == "" && refresh == "" &&
+		v1alpha1.OverridesFromAnnotations(pvc.Annotations) == nil && !populator.Templated(pop) && p.populateFromCache(pvc, pop) {
+		return nil
 	}
-	log.Printf("succesfully launch a populator job (%v) for PVC %s", job, pvc.Name)
-	// TODO
-	// * We'll need to add some tracking type data to the PVC in the form of attributes
+	// CreateJobFromPopulators creates the job spec and launches it
+	var job *batch.Job
+	message := ""
+	if refresh != "" {
+		job, err = populator.CreateRefreshJobFromPopulators(p.KubeClient, pvc, pops, p.jobDefaults(pvc), refresh)
+		message = "refreshing"
+	} else {
+		job, err = populator.CreateJobFromPopulators(p.KubeClient, pvc, pops, p.jobDefaults(pvc))
+	}
+	if locked, ok := err.(*populator.LockedError); ok {
+		// another job got to the PVC after we looked
+		return &WaitError{After: jobWait, Reason: fmt.Sprintf("population of PVC %s is waiting for job %s to finish", pvc.Name, locked.Job)}
+	}
+	if err != nil {
+		p.recordEvent(pvc, core_v1.EventTypeWarning, "PopulatorJobFailed", "unable to launch populator job: %v", err)
+		// templates that can't be rendered for the PVC, overrides it isn't allowed and the like won't go away by
+		// trying again, anything else (the API server, a quota) might
+		if _, invalid := err.(*populator.InvalidError); !invalid && !lastAttempt {
+			return err
+		}
+		p.failPopulation(pvc, refresh, fmt.Sprintf("unable to launch populator job: %v", err))
+		return err
+	}
+	// just the name, the spec can hold source URLs and secret names that have no business in the log
+	log.Printf("succesfully launch a populator job (%s) for PVC %s", job.Name, pvc.Name)
+	p.recordEvent(pvc, core_v1.EventTypeNormal, "PopulatorJobCreated", "launched populator job %s", job.Name)
+	for _, pop := range pops {
+		for _, w := range populator.Warnings(pop) {
+			p.recordEvent(pvc, core_v1.EventTypeWarning, "PopulatorDeprecated", "%s %s: %s", pvc.Spec.DataSource.Kind, pop.Name, w)
+		}
+	}
+	// the refresh request is only marked as handled once its job is running, a retry before then launches the same
+	// job (it's named after the request) and finds it already there
+	if refresh != "" && !p.markRefreshed(pvc, refresh) {
+		return fmt.Errorf("unable to record refresh of PVC %s", pvc.Name)
+	}
+	if err := setPopulationStatus(p.KubeClient, pvc, v1alpha1.PopulationRunning, job.Name, message); err != nil {
+		log.Printf("unable to record population status on PVC %s: %v", pvc.Name, err)
+	}
+	return nil
 }
 
 // ObjectDeleted is called when an object is deleted
@@ -69,3 +203,110 @@ func (p *PopulatorHandler) ObjectDeleted(obj interface{}) {
 func (p *PopulatorHandler) ObjectUpdated(objOld, objNew interface{}) {
 	log.Println("handle ObjectUpdated event")
 }
+
+// refreshRequested returns true if the PVC has a refresh annotation we haven't acted on yet
+func refreshRequested(pvc *core_v1.PersistentVolumeClaim) bool {
+	token := pvc.Annotations[v1alpha1.AnnRefresh]
+	return token != "" && token != pvc.Annotations[v1alpha1.AnnRefreshed]
+}
+
+// failPopulation marks the population failed for good.  A refresh request is marked as handled first so the
+// failure doesn't bring us straight back round for it
+func (p *PopulatorHandler) failPopulation(pvc *core_v1.PersistentVolumeClaim, refresh, message string) {
+	if refresh != "" && !p.markRefreshed(pvc, refresh) {
+		return
+	}
+	if err := setPopulationStatus(p.KubeClient, pvc, v1alpha1.PopulationFailed, "", message); err != nil {
+		log.Printf("unable to record population status on PVC %s: %v", pvc.Name, err)
+	}
+}
+
+// markRefreshed records that the PVC's refresh request has been handled, it returns false if it couldn't be
+func (p *PopulatorHandler) markRefreshed(pvc *core_v1.PersistentVolumeClaim, refresh string) bool {
+	if err := patchAnnotations(p.KubeClient, pvc, map[string]string{v1alpha1.AnnRefreshed: refresh}); err != nil {
+		log.Printf("unable to record refresh of PVC %s: %v", pvc.Name, err)
+		return false
+	}
+	return true
+}
+
+// preflight probes the sources of every Populator we're about to run, as the job will see them after the PVC's
+// templates and overrides, and records the result on each of them.  An unreachable source is reported with an event
+// and returned, the caller decides whether to try again or give up rather than leave it to a job that's bound to fail
+func (p *PopulatorHandler) preflight(pvc *core_v1.PersistentVolumeClaim, pops []*v1alpha1.Populator) error {
+	resolved, err := populator.ResolvePopulators(pvc, pops, nil)
+	if err != nil {
+		// there won't be a job to check the sources for, launching it reports why
+		return nil
+	}
+	var failed []string
+	for i, pop := range resolved {
+		err := populator.ProbeSources(pop)
+		p.setSourceReachable(pops[i], err)
+		if err != nil {
+			log.Printf("pre-flight check for Populator %s failed: %v", pop.Name, err)
+			failed = append(failed, fmt.Sprintf("Populator %s: %v", pop.Name, err))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	message := strings.Join(failed, "; ")
+	p.recordEvent(pvc, core_v1.EventTypeWarning, "SourceUnreachable", "%s", message)
+	return fmt.Errorf("%s", message)
+}
+
+// setSourceReachable updates the Populator's SourceReachable condition, it's only written when it changes
+func (p *PopulatorHandler) setSourceReachable(pop *v1alpha1.Populator, probeErr error) {
+	cond := metav1.Condition{
+		Type:               v1alpha1.ConditionSourceReachable,
+		Status:             metav1.ConditionTrue,
+		Reason:             v1alpha1.ReasonSourceReachable,
+		Message:            "all sources are reachable",
+		ObservedGeneration: pop.Generation,
+	}
+	if probeErr != nil {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = v1alpha1.ReasonSourceUnreachable
+		cond.Message = probeErr.Error()
+	}
+	if old := meta.FindStatusCondition(pop.Status.Conditions, cond.Type); old != nil &&
+		old.Status == cond.Status && old.Message == cond.Message && old.ObservedGeneration == cond.ObservedGeneration {
+		return
+	}
+	pop = pop.DeepCopy()
+	meta.SetStatusCondition(&pop.Status.Conditions, cond)
+	var err error
+	if pop.IsCluster() {
+		_, err = p.PopulatorClient.ClusterPopulators().UpdateStatus(context.TODO(), pop.AsClusterPopulator())
+	} else {
+		_, err = p.PopulatorClient.Populators(pop.Namespace).UpdateStatus(context.TODO(), pop)
+	}
+	if err != nil {
+		log.Printf("unable to update status of Populator %s: %v", pop.Name, err)
+	}
+}
+
+// jobDefaults loads the controller wide job template, if we can't read it we carry on without it rather than leave
+// the PVC empty, the job may well still be admitted
+func (p *PopulatorHandler) jobDefaults(pvc *core_v1.PersistentVolumeClaim) *v1alpha1.JobTemplate {
+	if p.JobTemplateConfigMap == "" {
+		return nil
+	}
+	t, err := populator.LoadJobTemplate(p.KubeClient, p.JobTemplateNamespace, p.JobTemplateConfigMap)
+	if err != nil {
+		log.Printf("unable to load default job template: %v", err)
+		p.recordEvent(pvc, core_v1.EventTypeWarning, "JobTemplateInvalid", "default job template not applied: %v", err)
+		return nil
+	}
+	return t
+}
+
+// recordEvent emits an event against the PVC if we've been given a Recorder, it's optional so that callers
+// using the handler outside of the manager don't have to set one up
+func (p *PopulatorHandler) recordEvent(pvc *core_v1.PersistentVolumeClaim, eventType, reason, messageFmt string, args ...interface{}) {
+	if p.Recorder == nil {
+		return
+	}
+	p.Recorder.Eventf(pvc, eventType, reason, messageFmt, args...)
+}
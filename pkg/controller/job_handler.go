@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	clientset "github.com/j-griffith/populator/pkg/clientset/v1alpha1"
+	"github.com/j-griffith/populator/pkg/populator"
+	batch "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+)
+
+// JobHandler watches populator jobs and records how they finished on the PVC they were populating (or the
+// PopulationRequest they were run for), a job that runs out of retries (BackoffLimit) or time
+// (ActiveDeadlineSeconds) marks the PVC failed
+type JobHandler struct {
+	KubeClient      kubernetes.Interface
+	PopulatorClient clientset.Interface
+	Recorder        record.EventRecorder
+}
+
+// Init handles any handler initialization
+func (h *JobHandler) Init() error {
+	log.Println("initialize JobHandler")
+	return nil
+}
+
+// ObjectCreated is called when a job is added or its status changes
+func (h *JobHandler) ObjectCreated(obj interface{}) {
+	job := obj.(*batch.Job)
+	pvcName := job.Labels[v1alpha1.LabelPVC]
+	if pvcName == "" {
+		return
+	}
+
+	status, message := jobOutcome(job)
+	if status == "" {
+		// still running, nothing to record yet
+		return
+	}
+
+	pvc, err := h.KubeClient.CoreV1().PersistentVolumeClaims(job.Namespace).Get(context.TODO(), pvcName, metav1.GetOptions{})
+	if err != nil {
+		log.Printf("unable to fetch PVC %s/%s for populator job %s: %v", job.Namespace, pvcName, job.Name, err)
+		return
+	}
+	if name := job.Labels[v1alpha1.LabelRequest]; name != "" {
+		// the PVC's own population status is left alone, the request records how its run went
+		h.requestFinished(job, pvc, name, status, message)
+		return
+	}
+	if populationStatus(pvc) == status {
+		return
+	}
+
+	log.Printf("populator job %s for PVC %s finished: %s %s", job.Name, pvc.Name, status, message)
+	if status == v1alpha1.PopulationSucceeded {
+		// record the manifest before the status, anybody waiting on Succeeded can then rely on it being there
+		if _, err := h.recordManifest(job, pvc); err != nil {
+			log.Printf("unable to record content manifest for PVC %s: %v", pvc.Name, err)
+			if h.Recorder != nil {
+				h.Recorder.Eventf(pvc, core_v1.EventTypeWarning, "ManifestUnavailable", "unable to record content manifest: %v", err)
+			}
+		}
+	}
+	if err := setPopulationStatus(h.KubeClient, pvc, status, job.Name, message); err != nil {
+		log.Printf("unable to record population status on PVC %s: %v", pvc.Name, err)
+		return
+	}
+	if h.Recorder == nil {
+		return
+	}
+	if status == v1alpha1.PopulationFailed {
+		h.Recorder.Eventf(pvc, core_v1.EventTypeWarning, "PopulationFailed", "populator job %s failed: %s", job.Name, message)
+	} else {
+		h.Recorder.Eventf(pvc, core_v1.EventTypeNormal, "PopulationSucceeded", "populator job %s completed", job.Name)
+	}
+}
+
+// ObjectDeleted is called when a job is deleted, which is normally just the TTL cleaning it up
+func (h *JobHandler) ObjectDeleted(obj interface{}) {
+	log.Println("handle JobHandler ObjectDeleted event")
+}
+
+// ObjectUpdated is called when a job is updated
+func (h *JobHandler) ObjectUpdated(objOld, objNew interface{}) {
+	log.Println("handle JobHandler ObjectUpdated event")
+}
+
+// requestFinished records the outcome of a PopulationRequest's job on the request, the content manifest of a
+// successful run is recorded on the PVC like any other population
+func (h *JobHandler) requestFinished(job *batch.Job, pvc *core_v1.PersistentVolumeClaim, name, status, message string) {
+	if h.PopulatorClient == nil {
+		return
+	}
+	requests := h.PopulatorClient.PopulationRequests(job.Namespace)
+	r, err := requests.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		log.Printf("unable to fetch PopulationRequest %s for populator job %s: %v", name, job.Name, err)
+		return
+	}
+	if r.Finished() {
+		return
+	}
+
+	log.Printf("populator job %s for PopulationRequest %s finished: %s %s", job.Name, r.Name, status, message)
+	digest := ""
+	if status == v1alpha1.PopulationSucceeded {
+		if digest, err = h.recordManifest(job, pvc); err != nil {
+			log.Printf("unable to record content manifest for PVC %s: %v", pvc.Name, err)
+		}
+	}
+	if err := updateRequestStatus(requests, r, status, job.Name, message, digest); err != nil {
+		log.Printf("unable to update status of PopulationRequest %s: %v", r.Name, err)
+		return
+	}
+	if h.Recorder == nil {
+		return
+	}
+	if status == v1alpha1.PopulationFailed {
+		h.Recorder.Eventf(r, core_v1.EventTypeWarning, "PopulationFailed", "populator job %s failed: %s", job.Name, message)
+	} else {
+		h.Recorder.Eventf(r, core_v1.EventTypeNormal, "PopulationSucceeded", "populator job %s completed", job.Name)
+	}
+}
+
+// recordManifest collects the step results from the job's successful pod and stores them on the PVC, steps
+// using images that don't write manifests simply don't show up.  It returns the content digest, if there is one
+func (h *JobHandler) recordManifest(job *batch.Job, pvc *core_v1.PersistentVolumeClaim) (string, error) {
+	pods, err := h.KubeClient.CoreV1().Pods(job.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: "job-name=" + job.Name})
+	if err != nil {
+		return "", err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != core_v1.PodSucceeded {
+			continue
+		}
+		results, err := populator.StepResultsFromPod(pod)
+		if err != nil {
+			return "", err
+		}
+		if len(results) == 0 {
+			return "", nil
+		}
+		manifest, err := json.Marshal(results)
+		if err != nil {
+			return "", err
+		}
+		digest := populator.ContentDigest(results)
+		return digest, patchAnnotations(h.KubeClient, pvc, map[string]string{
+			v1alpha1.AnnContentDigest: digest,
+			v1alpha1.AnnManifest:      string(manifest),
+		})
+	}
+	return "", fmt.Errorf("no successful pod found for job %s", job.Name)
+}
+
+// jobOutcome returns the population status for a finished job along with the reason it failed, or an empty
+// status if the job hasn't finished
+func jobOutcome(job *batch.Job) (string, string) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != core_v1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batch.JobComplete:
+			return v1alpha1.PopulationSucceeded, ""
+		case batch.JobFailed:
+			// Reason is BackoffLimitExceeded or DeadlineExceeded
+			return v1alpha1.PopulationFailed, c.Reason + ": " + c.Message
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	"github.com/j-griffith/populator/pkg/populator"
+	batch "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Only one job writes to a volume at a time.  Every populator job is labelled with its PVC, refreshes (requested
+// or scheduled) and PopulationRequests look them up before launching their own and wait for any that's still
+// running to finish.  Looking isn't enough to keep two of them from launching at once, the PVC's Lease taken by the
+// CreateJob functions is (a populator.LockedError says who got there first), this just saves building a job that
+// has to wait anyway
+
+// pvcJobs returns the populator jobs labelled with the PVC, finished or not
+func pvcJobs(c kubernetes.Interface, pvc *core_v1.PersistentVolumeClaim) ([]batch.Job, error) {
+	selector := labels.SelectorFromSet(labels.Set{"app": "populator", v1alpha1.LabelPVC: pvc.Name})
+	jobs, err := c.BatchV1().Jobs(pvc.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	return jobs.Items, nil
+}
+
+// activeJob returns the job still writing to the PVC, or nil if they've all finished
+func activeJob(jobs []batch.Job) *batch.Job {
+	for i := range jobs {
+		if !populator.JobFinished(&jobs[i]) {
+			return &jobs[i]
+		}
+	}
+	return nil
+}
+
+// pvcLocked returns the job holding the PVC, if there is one
+func pvcLocked(c kubernetes.Interface, pvc *core_v1.PersistentVolumeClaim) (*batch.Job, error) {
+	jobs, err := pvcJobs(c, pvc)
+	if err != nil {
+		return nil, err
+	}
+	return activeJob(jobs), nil
+}
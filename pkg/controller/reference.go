@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"context"
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	"github.com/j-griffith/populator/pkg/populator"
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// checkGrants makes sure the PVC may use pops, the Populator its dataSource names and everything that includes.
+// Populators in the PVC's own namespace and ClusterPopulators are always allowed, anything else needs a
+// PopulatorGrant in its namespace
+func (p *PopulatorHandler) checkGrants(pvc *core_v1.PersistentVolumeClaim, pops []*v1alpha1.Populator) error {
+	ns := populator.PopulatorNamespace(pvc)
+	if ns == "" || ns == pvc.Namespace {
+		return nil
+	}
+	grants, err := p.PopulatorClient.PopulatorGrants(ns).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	return populator.CheckGrants(grants.Items, pvc.Namespace, pops)
+}
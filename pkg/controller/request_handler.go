@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	"github.com/j-griffith/populator/pkg/populator"
+	batch "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RequestHandler runs PopulationRequests, it shares its clients and job defaults with the PVC handler.  A
+// request gets a single job, once it's launched the JobHandler records how it went
+type RequestHandler struct {
+	*PopulatorHandler
+}
+
+// Init handles any handler initialization
+func (h *RequestHandler) Init() error {
+	log.Println("initialize RequestHandler")
+	return nil
+}
+
+// ObjectCreated is called when a request is created, and again every resync until its job has been launched
+func (h *RequestHandler) ObjectCreated(obj interface{}) {
+	r := obj.(*v1alpha1.PopulationRequest)
+	if r.Finished() || r.Status.Phase == v1alpha1.PopulationRunning {
+		return
+	}
+
+	pvc, err := h.KubeClient.CoreV1().PersistentVolumeClaims(r.Namespace).Get(context.TODO(), r.Spec.PVC, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		h.setRequestStatus(r, v1alpha1.PopulationPending, "", fmt.Sprintf("waiting for PVC %s to be created", r.Spec.PVC))
+		return
+	}
+	if err != nil {
+		log.Printf("unable to fetch PVC %s for PopulationRequest %s: %v", r.Spec.PVC, r.Name, err)
+		return
+	}
+	if pvc.DeletionTimestamp != nil {
+		h.failRequest(r, "PVC %s is being deleted", pvc.Name)
+		return
+	}
+	job, err := h.pvcJob(pvc, r)
+	if err != nil {
+		log.Printf("unable to list jobs for PVC %s: %v", pvc.Name, err)
+		return
+	}
+	if job != nil && job.Labels[v1alpha1.LabelRequest] == r.Name {
+		// we launched it but didn't get as far as recording it, the JobHandler takes it from here
+		h.setRequestStatus(r, v1alpha1.PopulationRunning, job.Name, "")
+		return
+	}
+	// only one job writes to a volume at a time, we try again at the next resync
+	if job != nil {
+		h.setRequestStatus(r, v1alpha1.PopulationPending, "", fmt.Sprintf("waiting for job %s to finish", job.Name))
+		return
+	}
+	if status := populationStatus(pvc); v1alpha1.IsPopulatorDataSource(pvc.Spec.DataSource) &&
+		status != v1alpha1.PopulationSucceeded && status != v1alpha1.PopulationFailed {
+		h.setRequestStatus(r, v1alpha1.PopulationPending, "", fmt.Sprintf("waiting for PVC %s to be populated", pvc.Name))
+		return
+	}
+
+	pop, err := h.PopulatorClient.Populators(r.Namespace).Get(context.TODO(), r.Spec.Populator, metav1.GetOptions{})
+	if err != nil {
+		h.failRequest(r, "unable to fetch Populator %s: %v", r.Spec.Populator, err)
+		return
+	}
+	pops, err := populator.ResolveIncludes(func(name string) (*v1alpha1.Populator, error) {
+		return h.PopulatorClient.Populators(r.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	}, pop)
+	if err != nil {
+		h.failRequest(r, "%v", err)
+		return
+	}
+	job, err = populator.CreateJobFromRequest(h.KubeClient, pvc, pops, h.jobDefaults(pvc), r)
+	if locked, ok := err.(*populator.LockedError); ok {
+		h.setRequestStatus(r, v1alpha1.PopulationPending, "", fmt.Sprintf("waiting for job %s to finish", locked.Job))
+		return
+	}
+	if _, invalid := err.(*populator.InvalidError); invalid {
+		h.failRequest(r, "unable to launch populator job: %v", err)
+		return
+	}
+	if err != nil {
+		// it may well work next time, we try again at the next resync
+		log.Printf("unable to launch populator job for PopulationRequest %s: %v", r.Name, err)
+		return
+	}
+	log.Printf("launched populator job %s for PopulationRequest %s", job.Name, r.Name)
+	h.recordRequestEvent(r, core_v1.EventTypeNormal, "PopulatorJobCreated", "launched populator job %s for PVC %s", job.Name, pvc.Name)
+	h.setRequestStatus(r, v1alpha1.PopulationRunning, job.Name, "")
+}
+
+// Sync hides the one RequestHandler gets from the PVC handler, a request that can't be run yet is looked at again
+// at the next resync rather than retried through the queue
+func (h *RequestHandler) Sync(obj interface{}, lastAttempt bool) error {
+	h.ObjectCreated(obj)
+	return nil
+}
+
+// ObjectDeleted is called when a request is deleted, a job it launched is left to finish
+func (h *RequestHandler) ObjectDeleted(obj interface{}) {
+	log.Println("handle RequestHandler ObjectDeleted event")
+}
+
+// ObjectUpdated is called when a request is updated
+func (h *RequestHandler) ObjectUpdated(objOld, objNew interface{}) {
+	log.Println("handle RequestHandler ObjectUpdated event")
+}
+
+// pvcJob returns the populator job working on the PVC, if there is one (see pvcLocked).  A job already launched
+// for r is returned whether it's finished or not
+func (h *RequestHandler) pvcJob(pvc *core_v1.PersistentVolumeClaim, r *v1alpha1.PopulationRequest) (*batch.Job, error) {
+	jobs, err := pvcJobs(h.KubeClient, pvc)
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		if jobs[i].Labels[v1alpha1.LabelRequest] == r.Name {
+			return &jobs[i], nil
+		}
+	}
+	return activeJob(jobs), nil
+}
+
+func (h *RequestHandler) failRequest(r *v1alpha1.PopulationRequest, messageFmt string, args ...interface{}) {
+	message := fmt.Sprintf(messageFmt, args...)
+	log.Printf("PopulationRequest %s failed: %s", r.Name, message)
+	h.recordRequestEvent(r, core_v1.EventTypeWarning, "PopulationFailed", "%s", message)
+	h.setRequestStatus(r, v1alpha1.PopulationFailed, "", message)
+}
+
+// setRequestStatus records the phase of the request, it's only written when something changes so a request
+// waiting on its PVC doesn't get rewritten every resync
+func (h *RequestHandler) setRequestStatus(r *v1alpha1.PopulationRequest, phase, jobName, message string) {
+	if err := updateRequestStatus(h.PopulatorClient.PopulationRequests(r.Namespace), r, phase, jobName, message, ""); err != nil {
+		log.Printf("unable to update status of PopulationRequest %s: %v", r.Name, err)
+	}
+}
+
+func (h *RequestHandler) recordRequestEvent(r *v1alpha1.PopulationRequest, eventType, reason, messageFmt string, args ...interface{}) {
+	if h.Recorder == nil {
+		return
+	}
+	h.Recorder.Eventf(r, eventType, reason, messageFmt, args...)
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	clientset "github.com/j-griffith/populator/pkg/clientset/v1alpha1"
+	batch "k8s.io/api/batch/v1"
+	coordination "k8s.io/api/coordination/v1"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakePopulatorClient is just enough of the Populator clientset for the handlers, anything it doesn't override
+// panics through the nil embedded interfaces
+type fakePopulatorClient struct {
+	clientset.Interface
+	pops     map[string]*v1alpha1.Populator
+	requests []*v1alpha1.PopulationRequest // every status written, in order
+}
+
+func (f *fakePopulatorClient) Populators(namespace string) clientset.PopulatorInterface {
+	return &fakePopulators{f: f}
+}
+
+func (f *fakePopulatorClient) PopulationRequests(namespace string) clientset.PopulationRequestInterface {
+	return &fakeRequests{f: f}
+}
+
+type fakePopulators struct {
+	clientset.PopulatorInterface
+	f *fakePopulatorClient
+}
+
+func (p *fakePopulators) Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1alpha1.Populator, error) {
+	if pop, ok := p.f.pops[name]; ok {
+		return pop, nil
+	}
+	return nil, errors.NewNotFound(schema.GroupResource{Group: v1alpha1.GroupName, Resource: "populators"}, name)
+}
+
+type fakeRequests struct {
+	clientset.PopulationRequestInterface
+	f *fakePopulatorClient
+}
+
+func (r *fakeRequests) UpdateStatus(ctx context.Context, request *v1alpha1.PopulationRequest) (*v1alpha1.PopulationRequest, error) {
+	r.f.requests = append(r.f.requests, request)
+	return request, nil
+}
+
+func TestRequestHandlerObjectCreated(t *testing.T) {
+	pvc := &core_v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"}}
+	deleting := pvc.DeepCopy()
+	deleting.DeletionTimestamp = &metav1.Time{}
+	group := v1alpha1.GroupName
+	populating := pvc.DeepCopy()
+	populating.Spec.DataSource = &core_v1.TypedLocalObjectReference{APIGroup: &group, Kind: v1alpha1.Kind, Name: "pop"}
+	populating.Annotations = map[string]string{v1alpha1.AnnPopulationStatus: v1alpha1.PopulationRunning}
+	otherJob := &batch.Job{ObjectMeta: metav1.ObjectMeta{
+		Name:      "other",
+		Namespace: "default",
+		Labels:    map[string]string{"app": "populator", v1alpha1.LabelPVC: "data"},
+	}}
+	ownJob := otherJob.DeepCopy()
+	ownJob.Name = "own"
+	ownJob.Labels[v1alpha1.LabelRequest] = "req"
+	// a job launched since we listed them, it's only found through the PVC's lease
+	racer := &batch.Job{ObjectMeta: metav1.ObjectMeta{Name: "racer", Namespace: "default"}}
+	holder, seconds := "racer", int32(60)
+	lease := &coordination.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "populator-pvc-data", Namespace: "default"},
+		Spec:       coordination.LeaseSpec{HolderIdentity: &holder, LeaseDurationSeconds: &seconds},
+	}
+	pop := &v1alpha1.Populator{
+		ObjectMeta: metav1.ObjectMeta{Name: "pop", Namespace: "default"},
+		Spec: v1alpha1.PopulatorSpec{
+			Type:       v1alpha1.TypeGit,
+			Mountpoint: "/data",
+			Git:        v1alpha1.GitPopulator{Repo: "https://example.com/repo.git", Branch: "master"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		phase   string // Status.Phase the request starts in
+		want    string // phase written, empty if the status shouldn't be touched
+		job     string // the job the status should name
+		message string // a substring of the message written
+		missing bool   // the request names a Populator that doesn't exist
+		tag     string // the request overrides the tag, which the Populator doesn't allow
+	}{
+		{name: "finished", objects: []runtime.Object{pvc}, phase: v1alpha1.PopulationSucceeded},
+		{name: "already running", objects: []runtime.Object{pvc}, phase: v1alpha1.PopulationRunning},
+		{name: "no PVC", want: v1alpha1.PopulationPending, message: "waiting for PVC data"},
+		{name: "PVC being deleted", objects: []runtime.Object{deleting}, want: v1alpha1.PopulationFailed, message: "being deleted"},
+		{name: "another job running", objects: []runtime.Object{pvc, otherJob}, want: v1alpha1.PopulationPending, message: "waiting for job other"},
+		{name: "job already launched", objects: []runtime.Object{pvc, ownJob}, want: v1alpha1.PopulationRunning, job: "own"},
+		{name: "PVC not populated yet", objects: []runtime.Object{populating}, want: v1alpha1.PopulationPending, message: "to be populated"},
+		{name: "no Populator", objects: []runtime.Object{pvc}, missing: true, want: v1alpha1.PopulationFailed, message: "unable to fetch Populator missing"},
+		{name: "locked by a job launched since", objects: []runtime.Object{pvc, racer, lease}, want: v1alpha1.PopulationPending, message: "waiting for job racer"},
+		{name: "override not allowed", objects: []runtime.Object{pvc}, tag: "v1.0", want: v1alpha1.PopulationFailed, message: "tag"},
+		{name: "launched", objects: []runtime.Object{pvc}, want: v1alpha1.PopulationRunning},
+	}
+	for _, tt := range tests {
+		kube := fake.NewClientset(tt.objects...)
+		pops := &fakePopulatorClient{pops: map[string]*v1alpha1.Populator{"pop": pop}}
+		h := &RequestHandler{&PopulatorHandler{KubeClient: kube, PopulatorClient: pops}}
+		r := &v1alpha1.PopulationRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "req", Namespace: "default", UID: "1234"},
+			Spec:       v1alpha1.PopulationRequestSpec{PVC: "data", Populator: "pop"},
+			Status:     v1alpha1.PopulationRequestStatus{Phase: tt.phase},
+		}
+		if tt.missing {
+			r.Spec.Populator = "missing"
+		}
+		if tt.tag != "" {
+			r.Spec.Overrides = &v1alpha1.Overrides{Tag: tt.tag}
+		}
+
+		h.ObjectCreated(r)
+
+		if tt.want == "" {
+			if len(pops.requests) != 0 {
+				t.Errorf("%s: status written %+v, want it left alone", tt.name, pops.requests[0].Status)
+			}
+			continue
+		}
+		if len(pops.requests) != 1 {
+			t.Errorf("%s: status written %d times, want once", tt.name, len(pops.requests))
+			continue
+		}
+		status := pops.requests[0].Status
+		if status.Phase != tt.want || !strings.Contains(status.Message, tt.message) {
+			t.Errorf("%s: status %s %q, want %s %q", tt.name, status.Phase, status.Message, tt.want, tt.message)
+		}
+		if tt.job != "" && status.Job != tt.job {
+			t.Errorf("%s: status names job %q, want %q", tt.name, status.Job, tt.job)
+		}
+		if tt.name != "launched" {
+			continue
+		}
+		jobs, err := kube.BatchV1().Jobs("default").List(context.TODO(), metav1.ListOptions{})
+		if err != nil || len(jobs.Items) != 1 {
+			t.Errorf("%s: got jobs %v (%v), want one", tt.name, jobs, err)
+			continue
+		}
+		if job := jobs.Items[0]; job.Labels[v1alpha1.LabelRequest] != "req" || status.Job != job.Name {
+			t.Errorf("%s: launched job %s with labels %v, status names %q", tt.name, job.Name, job.Labels, status.Job)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	clientset "github.com/j-griffith/populator/pkg/clientset/v1alpha1"
+	"github.com/j-griffith/populator/pkg/populator"
+	"github.com/robfig/cron/v3"
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RefreshScheduler keeps PVCs in sync with their sources on a cron schedule, taken from the PVC's
+// AnnRefreshSchedule annotation or else its Populator's RefreshSchedule.  It doesn't run anything itself, when a
+// PVC is due it sets AnnRefresh and the handler refreshes it like any other refresh request
+type RefreshScheduler struct {
+	KubeClient      kubernetes.Interface
+	PopulatorClient clientset.Interface
+	Namespace       string
+	// Interval is how often schedules are checked, there's no point in it being shorter than a minute
+	Interval time.Duration
+}
+
+// Run checks for PVCs that are due a refresh every Interval until stopCh is closed
+func (s *RefreshScheduler) Run(stopCh <-chan struct{}) {
+	log.Printf("checking refresh schedules in namespace %s every %v", s.Namespace, s.Interval)
+	wait.Until(s.check, s.Interval, stopCh)
+}
+
+func (s *RefreshScheduler) check() {
+	pvcs, err := s.KubeClient.CoreV1().PersistentVolumeClaims(s.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		log.Printf("unable to list PVCs for scheduled refreshes: %v", err)
+		return
+	}
+	now := time.Now()
+	// Populators are looked up once per check however many PVCs use them
+	pops := map[string]*v1alpha1.Populator{}
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		if !v1alpha1.IsPopulatorDataSource(pvc.Spec.DataSource) || pvc.Labels[v1alpha1.LabelGolden] != "" {
+			// golden PVCs are replaced when their Populator changes, refreshing them wouldn't reach the copies
+			continue
+		}
+		// only volumes at rest are refreshed, a population that's still going will be caught next time round
+		if status := populationStatus(pvc); status != v1alpha1.PopulationSucceeded && status != v1alpha1.PopulationFailed {
+			continue
+		}
+		schedule, ok := pvc.Annotations[v1alpha1.AnnRefreshSchedule]
+		if !ok {
+			ns, name := populator.PopulatorNamespace(pvc), pvc.Spec.DataSource.Name
+			pop, seen := pops[ns+"/"+name]
+			if !seen {
+				if pop, err = populator.DataSourceGetter(s.PopulatorClient, pvc)(name); err != nil {
+					log.Printf("unable to fetch %s %s for PVC %s: %v", pvc.Spec.DataSource.Kind, name, pvc.Name, err)
+					pop = nil
+				}
+				pops[ns+"/"+name] = pop
+			}
+			if pop == nil {
+				continue
+			}
+			schedule = pop.Spec.RefreshSchedule
+		}
+		if schedule == "" {
+			continue
+		}
+		due, err := refreshDue(pvc, schedule, now)
+		if err != nil {
+			log.Printf("invalid refresh schedule %q for PVC %s: %v", schedule, pvc.Name, err)
+			continue
+		}
+		if !due {
+			continue
+		}
+		// the token is the time we noticed rather than when the run was due, if we've been down for a while the
+		// missed runs turn into a single refresh rather than one after another
+		token := now.UTC().Format(time.RFC3339)
+		log.Printf("PVC %s is due a scheduled refresh (%s)", pvc.Name, schedule)
+		if err := patchAnnotations(s.KubeClient, pvc, map[string]string{
+			v1alpha1.AnnRefresh:              token,
+			v1alpha1.AnnLastScheduledRefresh: token,
+		}); err != nil {
+			log.Printf("unable to request scheduled refresh of PVC %s: %v", pvc.Name, err)
+		}
+	}
+}
+
+// refreshDue returns true if schedule has come round since the PVC was last refreshed on schedule, or since it was
+// created if it never has been
+func refreshDue(pvc *core_v1.PersistentVolumeClaim, schedule string, now time.Time) (bool, error) {
+	sched, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return false, err
+	}
+	last := pvc.CreationTimestamp.Time
+	if ann := pvc.Annotations[v1alpha1.AnnLastScheduledRefresh]; ann != "" {
+		if t, err := time.Parse(time.RFC3339, ann); err == nil {
+			last = t
+		}
+	}
+	return !sched.Next(last).After(now), nil
+}
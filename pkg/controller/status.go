@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	clientset "github.com/j-griffith/populator/pkg/clientset/v1alpha1"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// setPopulationStatus records the state of the population in the PVC's annotations, an empty jobName or message
+// leaves that annotation as it is
+func setPopulationStatus(c kubernetes.Interface, pvc *core_v1.PersistentVolumeClaim, status, jobName, message string) error {
+	annotations := map[string]string{
+		v1alpha1.AnnPopulationStatus: status,
+	}
+	if jobName != "" {
+		annotations[v1alpha1.AnnPopulationJob] = jobName
+	}
+	if message != "" {
+		annotations[v1alpha1.AnnPopulationMessage] = message
+	}
+	return patchAnnotations(c, pvc, annotations)
+}
+
+// patchAnnotations merges annotations into the PVC's existing ones
+func patchAnnotations(c kubernetes.Interface, pvc *core_v1.PersistentVolumeClaim, annotations map[string]string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.CoreV1().PersistentVolumeClaims(pvc.Namespace).Patch(context.TODO(), pvc.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// patchLabels merges labels into the PVC's existing ones
+func patchLabels(c kubernetes.Interface, pvc *core_v1.PersistentVolumeClaim, labels map[string]string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": labels,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.CoreV1().PersistentVolumeClaims(pvc.Namespace).Patch(context.TODO(), pvc.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// removeLabel deletes a label from the PVC, a merge patch removes a key set to null
+func removeLabel(c kubernetes.Interface, pvc *core_v1.PersistentVolumeClaim, key string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{key: nil},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.CoreV1().PersistentVolumeClaims(pvc.Namespace).Patch(context.TODO(), pvc.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// populationStatus returns the population state recorded on the PVC, or an empty string if we haven't touched it
+func populationStatus(pvc *core_v1.PersistentVolumeClaim) string {
+	return pvc.Annotations[v1alpha1.AnnPopulationStatus]
+}
+
+// updateRequestStatus records a PopulationRequest's phase and why it's in it, an empty jobName or digest leaves that
+// field as it is.  The start and completion times are filled in as the request reaches those phases
+func updateRequestStatus(c clientset.PopulationRequestInterface, r *v1alpha1.PopulationRequest, phase, jobName, message, digest string) error {
+	status := *r.Status.DeepCopy()
+	status.Phase = phase
+	status.Message = message
+	if jobName != "" {
+		status.Job = jobName
+	}
+	if digest != "" {
+		status.ContentDigest = digest
+	}
+	now := metav1.Now()
+	if phase == v1alpha1.PopulationRunning && status.StartTime == nil {
+		status.StartTime = &now
+	}
+	if (phase == v1alpha1.PopulationSucceeded || phase == v1alpha1.PopulationFailed) && status.CompletionTime == nil {
+		status.CompletionTime = &now
+	}
+	if equality.Semantic.DeepEqual(status, r.Status) {
+		return nil
+	}
+	r = r.DeepCopy()
+	r.Status = status
+	_, err := c.UpdateStatus(context.TODO(), r)
+	return err
+}
@@ -0,0 +1,111 @@
+package populator
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// gitType clones a branch or tag of a git repo with the git-populator image
+type gitType struct{}
+
+func (gitType) Name() string { return v1alpha1.TypeGit }
+
+// gitSecretKeys are the keys of the Populator's secret_ref the git populator uses, any of them may be missing.
+// They're the keys of the kubernetes.io/basic-auth and kubernetes.io/ssh-auth secret types, plus known_hosts for
+// checking ssh host keys
+var gitSecretKeys = []string{"username", "password", "ssh-privatekey", "known_hosts"}
+
+// AllowURLCredentials lets git repo URLs with credentials through validation again, with a warning (see
+// Warnings), for installs that still have Populators written that way.  It's set by the manager's
+// -allow-url-credentials flag and will be removed once the deprecation period is over
+var AllowURLCredentials bool
+
+func (gitType) Validate(src *v1alpha1.Source, fldPath *field.Path) field.ErrorList {
+	if src.Git == nil || src.Git.Repo == "" {
+		return field.ErrorList{field.Required(fldPath.Child("git", "repo"), "a repo URL is required for git populators")}
+	}
+	allErrs := field.ErrorList{}
+	// the value isn't echoed back, it's the bit we don't want anyone to see
+	if hasURLCredentials(src) && !AllowURLCredentials {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("git", "repo"), "credentials can't be part of the repo URL, put them in the secret named by secret_ref"))
+	}
+	// the repo and ref are passed to git as they are, templates are checked again once they're rendered
+	if strings.HasPrefix(src.Git.Repo, "-") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("git", "repo"), src.Git.Repo, `must not start with "-"`))
+	}
+	if src.Git.Branch != "" && !isTemplate(src.Git.Branch) {
+		allErrs = append(allErrs, v1alpha1.ValidateGitRef(src.Git.Branch, fldPath.Child("git", "branch"))...)
+	}
+	if src.Git.Tag != "" && !isTemplate(src.Git.Tag) {
+		allErrs = append(allErrs, v1alpha1.ValidateGitRef(src.Git.Tag, fldPath.Child("git", "tag"))...)
+	}
+	return allErrs
+}
+
+// hasURLCredentials returns true if src is a git source with credentials in its repo URL
+func hasURLCredentials(src *v1alpha1.Source) bool {
+	return src.Git != nil && RedactURL(src.Git.Repo) != src.Git.Repo
+}
+
+func (gitType) BuildStep(src *v1alpha1.Source, opts StepOptions) (*JobStep, error) {
+	if src.Git == nil {
+		return nil, fmt.Errorf("type git but no git source")
+	}
+	log.Printf("creating step for git-populator: %s into %s", RedactURL(src.Git.Repo), opts.Dest)
+	ref := src.Git.Branch
+	if src.Git.Tag != "" {
+		ref = src.Git.Tag
+	}
+	image := src.Image
+	if image == "" {
+		image = GitPopulatorImage
+	}
+	step := &JobStep{
+		Image: image,
+		Args:  []string{src.Git.Repo, ref, opts.Dest},
+	}
+	if opts.SecretRef != "" {
+		for _, key := range gitSecretKeys {
+			step.SecretKeys = append(step.SecretKeys, SecretKey{Key: key, Path: key, Optional: true})
+		}
+	}
+	return step, nil
+}
+
+func (gitType) ParseResult(message string) (*StepResult, error) {
+	return ParseStepResult(message)
+}
+
+func (gitType) Probe(src *v1alpha1.Source) error {
+	return probeGit(src)
+}
+
+// s3Type copies objects out of a bucket, there's no image for it yet so only validation and the pre-flight
+// check work
+type s3Type struct{}
+
+func (s3Type) Name() string { return v1alpha1.TypeS3 }
+
+func (s3Type) Validate(src *v1alpha1.Source, fldPath *field.Path) field.ErrorList {
+	if src.S3 == nil || src.S3.Bucket == "" {
+		return field.ErrorList{field.Required(fldPath.Child("s3", "bucket"), "a bucket is required for s3 populators")}
+	}
+	return nil
+}
+
+func (s3Type) BuildStep(src *v1alpha1.Source, opts StepOptions) (*JobStep, error) {
+	log.Printf("Sorry, not implemented yet")
+	return nil, fmt.Errorf("the s3 Populator Type isn't implemented yet")
+}
+
+func (s3Type) ParseResult(message string) (*StepResult, error) {
+	return ParseStepResult(message)
+}
+
+func (s3Type) Probe(src *v1alpha1.Source) error {
+	return probeS3(src)
+}
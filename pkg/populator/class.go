@@ -0,0 +1,180 @@
+package populator
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// classType is a PopulatorType defined by a PopulatorClass object rather than compiled in
+type classType struct {
+	class *v1alpha1.PopulatorClass
+	args  []*template.Template
+	env   []*template.Template
+}
+
+// classData is what a class's args and env templates are rendered with
+type classData struct {
+	Parameters map[string]string
+	Dest       string
+	Subpath    string
+	CacheDir   string // empty if there's no download cache
+	Refresh    bool   // the destination has been populated before, see StepOptions
+	SecretDir  string // where the class's secret_files are, empty if it doesn't have any
+}
+
+// SetClass makes a PopulatorClass available as a populator type, replacing any earlier version of it.  Classes
+// are looked up after the registered types so a class can't take over a built in type.  The controller keeps
+// these in sync with the PopulatorClass objects in the cluster
+func SetClass(class *v1alpha1.PopulatorClass) error {
+	t, err := newClassType(class)
+	if err != nil {
+		return err
+	}
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	classes[class.Name] = t
+	return nil
+}
+
+// RemoveClass forgets a PopulatorClass set with SetClass
+func RemoveClass(name string) {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	delete(classes, name)
+}
+
+func newClassType(class *v1alpha1.PopulatorClass) (*classType, error) {
+	if class.Spec.Image == "" {
+		return nil, fmt.Errorf("PopulatorClass %s has no image", class.Name)
+	}
+	for _, f := range class.Spec.SecretFiles {
+		if f.Path == "" || path.IsAbs(f.Path) || strings.HasPrefix(path.Clean(f.Path), "..") {
+			return nil, fmt.Errorf("PopulatorClass %s: secret file %q must be a relative path inside the secret directory", class.Name, f.Path)
+		}
+	}
+	t := &classType{class: class.DeepCopy()}
+	for i, arg := range class.Spec.Args {
+		tmpl, err := template.New(fmt.Sprintf("args[%d]", i)).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("PopulatorClass %s: %v", class.Name, err)
+		}
+		t.args = append(t.args, tmpl)
+	}
+	for _, env := range class.Spec.Env {
+		tmpl, err := template.New("env " + env.Name).Option("missingkey=error").Parse(env.Value)
+		if err != nil {
+			return nil, fmt.Errorf("PopulatorClass %s: %v", class.Name, err)
+		}
+		t.env = append(t.env, tmpl)
+	}
+	return t, nil
+}
+
+func (t *classType) Name() string { return t.class.Name }
+
+func (t *classType) Validate(src *v1alpha1.Source, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if len(t.class.Spec.Parameters) == 0 {
+		return allErrs
+	}
+	declared := map[string]bool{}
+	for _, p := range t.class.Spec.Parameters {
+		declared[p.Name] = true
+		if _, ok := src.Parameters[p.Name]; p.Required && !ok {
+			allErrs = append(allErrs, field.Required(fldPath.Child("parameters").Key(p.Name), fmt.Sprintf("required by PopulatorClass %s", t.class.Name)))
+		}
+	}
+	for name := range src.Parameters {
+		if !declared[name] {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("parameters").Key(name), name, t.parameterNames()))
+		}
+	}
+	return allErrs
+}
+
+func (t *classType) BuildStep(src *v1alpha1.Source, opts StepOptions) (*JobStep, error) {
+	fldPath := opts.Path
+	if fldPath == nil {
+		fldPath = field.NewPath("spec")
+	}
+	if errs := t.Validate(src, fldPath); len(errs) > 0 {
+		return nil, errs.ToAggregate()
+	}
+	data := classData{
+		Parameters: map[string]string{},
+		Dest:       opts.Dest,
+		Subpath:    src.Subpath,
+		CacheDir:   opts.CacheDir,
+		Refresh:    opts.Refresh,
+	}
+	if len(t.class.Spec.SecretFiles) > 0 {
+		data.SecretDir = SecretMountPath
+	}
+	for _, p := range t.class.Spec.Parameters {
+		if p.Default != "" {
+			data.Parameters[p.Name] = p.Default
+		}
+	}
+	for k, v := range src.Parameters {
+		data.Parameters[k] = v
+	}
+
+	image := src.Image
+	if image == "" {
+		image = t.class.Spec.Image
+	}
+	step := &JobStep{Image: image, Command: t.class.Spec.Command}
+	for _, tmpl := range t.args {
+		arg, err := render(tmpl, data)
+		if err != nil {
+			return nil, err
+		}
+		step.Args = append(step.Args, arg)
+	}
+	for i, tmpl := range t.env {
+		value, err := render(tmpl, data)
+		if err != nil {
+			return nil, err
+		}
+		step.Env = append(step.Env, core_v1.EnvVar{Name: t.class.Spec.Env[i].Name, Value: value})
+	}
+	if len(t.class.Spec.SecretEnv) > 0 || len(t.class.Spec.SecretFiles) > 0 {
+		if opts.SecretRef == "" {
+			return nil, fmt.Errorf("PopulatorClass %s needs a secret_ref", t.class.Name)
+		}
+		for _, s := range t.class.Spec.SecretEnv {
+			step.SecretKeys = append(step.SecretKeys, SecretKey{Key: s.Key, Env: s.Name, Optional: s.Optional})
+		}
+		for _, s := range t.class.Spec.SecretFiles {
+			step.SecretKeys = append(step.SecretKeys, SecretKey{Key: s.Key, Path: s.Path, Optional: s.Optional})
+		}
+	}
+	return step, nil
+}
+
+func (t *classType) ParseResult(message string) (*StepResult, error) {
+	return ParseStepResult(message)
+}
+
+func (t *classType) parameterNames() []string {
+	var names []string
+	for _, p := range t.class.Spec.Parameters {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func render(tmpl *template.Template, data classData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
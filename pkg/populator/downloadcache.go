@@ -0,0 +1,109 @@
+package populator
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	batch "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The shared download cache is a ReadWriteMany PVC mounted into every source step, so repeated populations of the
+// same source only fetch what's changed upstream.  Its layout is a convention between the populator images and
+// the eviction job:
+//
+//	git/<sha256 of the scope and repo URL>.git  bare mirrors kept by the git populator
+//	locks/<name>.lock                           flock(1) held while an entry (named without its .git suffix) is in use
+//
+// Entries are evicted least recently used first, so images should touch an entry whenever they use it.  Anything a
+// step keeps in the cache is keyed by EnvCacheScope as well as the source, a mirror fetched with one secret's
+// credentials mustn't be handed to a Populator that doesn't have them
+const (
+	DownloadCacheMountPath = "/populator-cache"
+	EnvCacheDir            = "POPULATOR_CACHE_DIR"
+	EnvCacheScope          = "POPULATOR_CACHE_SCOPE"
+
+	downloadCacheVolume = "populator-cache"
+)
+
+// DefaultDownloadCacheClaim names the download cache PVC, it's empty (no cache) unless the manager's
+// -download-cache-claim flag sets it.  A PVC can only be mounted in its own namespace, so jobs in any other
+// namespace than DownloadCacheNamespace go without and fetch everything afresh
+var (
+	DefaultDownloadCacheClaim string
+	DownloadCacheNamespace    = metav1.NamespaceDefault
+)
+
+// CacheEvictionImage runs the eviction script, it only needs a shell, du and flock.  It's pinned by digest as
+// the job has the whole cache mounted
+const CacheEvictionImage = "busybox@sha256:95cf004f559831017cdf4628aaf1bb30133677be8702a8c5f2994629f637a209"
+
+// evictScript deletes the least recently used cache entries that aren't locked until the cache is back under $2
+// KiB, $1 is the cache directory
+const evictScript = `set -u
+cd "$1"
+mkdir -p locks
+for entry in $(ls -1dtr git/*.git 2>/dev/null); do
+  [ "$(du -sk . | cut -f1)" -le "$2" ] && break
+  lock="locks/$(basename "$entry" .git).lock"
+  ( flock -n 9 && rm -rf "$entry" && echo "evicted $entry" ) 9>"$lock" || echo "$entry is in use, skipping it"
+done
+echo "cache is using $(du -sk . | cut -f1) KiB of $2 KiB"
+`
+
+// cacheScope returns the EnvCacheScope of a step, the namespace it runs in and the secret it fetches with (if any)
+func cacheScope(namespace, secret string) string {
+	return namespace + "/" + secret
+}
+
+// downloadCacheMount returns the volume and mount for the download cache claim
+func downloadCacheMount(claim string) (core_v1.Volume, core_v1.VolumeMount) {
+	volume := core_v1.Volume{
+		Name: downloadCacheVolume,
+		VolumeSource: core_v1.VolumeSource{
+			PersistentVolumeClaim: &core_v1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+		},
+	}
+	mount := core_v1.VolumeMount{Name: downloadCacheVolume, MountPath: DownloadCacheMountPath}
+	return volume, mount
+}
+
+// BuildCacheEvictionJob returns a job that trims the download cache in claim back under limitBytes
+func BuildCacheEvictionJob(claim string, limitBytes int64) *batch.Job {
+	volume, mount := downloadCacheMount(claim)
+	ttl := DefaultTTLSecondsAfterFinished
+	backoffLimit := int32(0)
+	labels := map[string]string{"app": "populator-cache-evict"}
+	return &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("populator-cache-evict-%d", time.Now().Unix()),
+			Labels: labels,
+		},
+		Spec: batch.JobSpec{
+			TTLSecondsAfterFinished: &ttl,
+			// the next run will try again
+			BackoffLimit: &backoffLimit,
+			Template: core_v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: core_v1.PodSpec{
+					Containers: []core_v1.Container{{
+						Name:            "evict",
+						Image:           CacheEvictionImage,
+						Command:         []string{"sh", "-c", evictScript, "--", DownloadCacheMountPath, strconv.FormatInt(limitBytes/1024, 10)},
+						SecurityContext: restrictedSecurityContext(),
+						VolumeMounts:    []core_v1.VolumeMount{mount},
+					}},
+					RestartPolicy: core_v1.RestartPolicyNever,
+					SecurityContext: &core_v1.PodSecurityContext{
+						SeccompProfile: &core_v1.SeccompProfile{
+							Type: core_v1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+					Volumes: []core_v1.Volume{volume},
+				},
+			},
+		},
+	}
+}
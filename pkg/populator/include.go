@@ -0,0 +1,68 @@
+package populator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+)
+
+// PopulatorGetter fetches a Populator by name, from whatever namespace the caller is resolving in
+type PopulatorGetter func(name string) (*v1alpha1.Populator, error)
+
+// IncludeCycleError is returned by ResolveIncludes when Populators include each other
+type IncludeCycleError struct {
+	Path []string
+}
+
+func (e *IncludeCycleError) Error() string {
+	return fmt.Sprintf("Populator include cycle: %s", strings.Join(e.Path, " -> "))
+}
+
+// ResolveIncludes walks p's include graph and returns every Populator to run, in order.  Includes are populated
+// depth first in the order they're listed and before the Populator that includes them, so p itself is always
+// last.  A Populator included more than once (say two includes sharing a base) only runs the first time
+func ResolveIncludes(get PopulatorGetter, p *v1alpha1.Populator) ([]*v1alpha1.Populator, error) {
+	r := &includeResolver{
+		get:      get,
+		visiting: map[string]bool{},
+		done:     map[string]bool{},
+	}
+	if err := r.visit(p, nil); err != nil {
+		return nil, err
+	}
+	return r.order, nil
+}
+
+type includeResolver struct {
+	get      PopulatorGetter
+	visiting map[string]bool
+	done     map[string]bool
+	order    []*v1alpha1.Populator
+}
+
+func (r *includeResolver) visit(p *v1alpha1.Populator, path []string) error {
+	path = append(path, p.Name)
+	r.visiting[p.Name] = true
+
+	for _, name := range p.Spec.Include {
+		if r.visiting[name] {
+			return &IncludeCycleError{Path: append(path, name)}
+		}
+		if r.done[name] {
+			continue
+		}
+		included, err := r.get(name)
+		if err != nil {
+			return fmt.Errorf("unable to fetch Populator %s included by %s: %v", name, p.Name, err)
+		}
+		if err := r.visit(included, path); err != nil {
+			return err
+		}
+	}
+
+	r.visiting[p.Name] = false
+	r.done[p.Name] = true
+	r.order = append(r.order, p)
+	return nil
+}
@@ -0,0 +1,100 @@
+package populator
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResolveIncludes(t *testing.T) {
+	tests := []struct {
+		name     string
+		includes map[string][]string // Populator name to the Populators it includes, "root" is resolved
+		order    []string
+		cycle    []string
+		wantErr  bool
+	}{
+		{name: "no includes", includes: map[string][]string{"root": nil}, order: []string{"root"}},
+		{
+			name:     "includes run first in order",
+			includes: map[string][]string{"root": {"a", "b"}, "a": nil, "b": nil},
+			order:    []string{"a", "b", "root"},
+		},
+		{
+			name:     "depth first",
+			includes: map[string][]string{"root": {"a", "b"}, "a": {"c"}, "b": nil, "c": nil},
+			order:    []string{"c", "a", "b", "root"},
+		},
+		{
+			name:     "shared base only runs once",
+			includes: map[string][]string{"root": {"a", "b"}, "a": {"base"}, "b": {"base"}, "base": nil},
+			order:    []string{"base", "a", "b", "root"},
+		},
+		{
+			name:     "listed twice",
+			includes: map[string][]string{"root": {"a", "a"}, "a": nil},
+			order:    []string{"a", "root"},
+		},
+		{
+			name:     "includes itself",
+			includes: map[string][]string{"root": {"root"}},
+			cycle:    []string{"root", "root"},
+		},
+		{
+			name:     "longer cycle",
+			includes: map[string][]string{"root": {"a"}, "a": {"b"}, "b": {"root"}},
+			cycle:    []string{"root", "a", "b", "root"},
+		},
+		{
+			name:     "cycle below the root",
+			includes: map[string][]string{"root": {"a"}, "a": {"b"}, "b": {"a"}},
+			cycle:    []string{"root", "a", "b", "a"},
+		},
+		{
+			name:     "missing include",
+			includes: map[string][]string{"root": {"missing"}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		pops := map[string]*v1alpha1.Populator{}
+		for name, includes := range tt.includes {
+			pops[name] = &v1alpha1.Populator{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: v1alpha1.PopulatorSpec{Include: includes}}
+		}
+		get := func(name string) (*v1alpha1.Populator, error) {
+			if p, ok := pops[name]; ok {
+				return p, nil
+			}
+			return nil, fmt.Errorf("populator %s not found", name)
+		}
+
+		resolved, err := ResolveIncludes(get, pops["root"])
+		if tt.cycle != nil {
+			cycleErr, ok := err.(*IncludeCycleError)
+			if !ok || !reflect.DeepEqual(cycleErr.Path, tt.cycle) {
+				t.Errorf("%s: got error %v, want cycle %v", tt.name, err, tt.cycle)
+			}
+			continue
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: resolved %d Populators, want an error", tt.name, len(resolved))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		var order []string
+		for _, p := range resolved {
+			order = append(order, p.Name)
+		}
+		if !reflect.DeepEqual(order, tt.order) {
+			t.Errorf("%s: resolved %v, want %v", tt.name, order, tt.order)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package populator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	"github.com/j-griffith/populator/pkg/api/types/v1beta1"
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+)
+
+// JobTemplateConfigMapKey is the key in the controller's ConfigMap holding the default job template, it's
+// written in the v1beta1 (camelCase) form, the same as a Populator's spec.jobTemplate
+const JobTemplateConfigMapKey = "jobTemplate"
+
+// LoadJobTemplate reads the controller wide default job template from a ConfigMap, a ConfigMap without the
+// jobTemplate key is fine and just means there are no defaults
+func LoadJobTemplate(c kubernetes.Interface, namespace, name string) (*v1alpha1.JobTemplate, error) {
+	cm, err := c.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	data, ok := cm.Data[JobTemplateConfigMapKey]
+	if !ok {
+		return nil, nil
+	}
+	t := &v1beta1.JobTemplate{}
+	if err := yaml.NewYAMLOrJSONDecoder(bytes.NewBufferString(data), 4096).Decode(t); err != nil {
+		return nil, fmt.Errorf("invalid %s in ConfigMap %s/%s: %v", JobTemplateConfigMapKey, namespace, name, err)
+	}
+	return v1beta1.ConvertJobTemplateToV1alpha1(t), nil
+}
+
+// MergeJobTemplates returns a new template with the fields set in override layered on top of defaults.  Maps
+// (resources, node selector) are merged key by key, everything else is replaced if override sets it, except
+// image pull secrets which are combined since the defaults usually name a cluster wide registry secret
+func MergeJobTemplates(defaults, override *v1alpha1.JobTemplate) *v1alpha1.JobTemplate {
+	if defaults == nil {
+		return override.DeepCopy()
+	}
+	out := defaults.DeepCopy()
+	if override == nil {
+		return out
+	}
+	override = override.DeepCopy()
+
+	out.Resources.Limits = mergeResourceList(out.Resources.Limits, override.Resources.Limits)
+	out.Resources.Requests = mergeResourceList(out.Resources.Requests, override.Resources.Requests)
+	for k, v := range override.NodeSelector {
+		if out.NodeSelector == nil {
+			out.NodeSelector = map[string]string{}
+		}
+		out.NodeSelector[k] = v
+	}
+	if override.Affinity != nil {
+		out.Affinity = override.Affinity
+	}
+	if len(override.Tolerations) > 0 {
+		out.Tolerations = override.Tolerations
+	}
+	if override.PriorityClassName != "" {
+		out.PriorityClassName = override.PriorityClassName
+	}
+	if override.ServiceAccountName != "" {
+		out.ServiceAccountName = override.ServiceAccountName
+	}
+	if override.RunAsUser != nil {
+		out.RunAsUser = override.RunAsUser
+	}
+	if override.FSGroup != nil {
+		out.FSGroup = override.FSGroup
+	}
+	for _, s := range override.ImagePullSecrets {
+		if !containsSecret(out.ImagePullSecrets, s.Name) {
+			out.ImagePullSecrets = append(out.ImagePullSecrets, s)
+		}
+	}
+	return out
+}
+
+func mergeResourceList(base, override core_v1.ResourceList) core_v1.ResourceList {
+	if len(override) == 0 {
+		return base
+	}
+	if base == nil {
+		base = core_v1.ResourceList{}
+	}
+	for k, v := range override {
+		base[k] = v
+	}
+	return base
+}
+
+func containsSecret(secrets []core_v1.LocalObjectReference, name string) bool {
+	for _, s := range secrets {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applyJobTemplate sets the template fields on the job's pod, every step gets the same resources since they
+// run one after the other
+func applyJobTemplate(pod *core_v1.PodSpec, t *v1alpha1.JobTemplate) {
+	if t == nil {
+		return
+	}
+	for i := range pod.InitContainers {
+		pod.InitContainers[i].Resources = *t.Resources.DeepCopy()
+	}
+	for i := range pod.Containers {
+		pod.Containers[i].Resources = *t.Resources.DeepCopy()
+	}
+	pod.NodeSelector = t.NodeSelector
+	pod.Affinity = t.Affinity
+	pod.Tolerations = t.Tolerations
+	pod.PriorityClassName = t.PriorityClassName
+	pod.ServiceAccountName = t.ServiceAccountName
+	pod.ImagePullSecrets = t.ImagePullSecrets
+	if t.RunAsUser != nil {
+		pod.SecurityContext.RunAsUser = t.RunAsUser
+		nonRoot := *t.RunAsUser != 0
+		pod.SecurityContext.RunAsNonRoot = &nonRoot
+	}
+	pod.SecurityContext.FSGroup = t.FSGroup
+}
@@ -0,0 +1,104 @@
+package populator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestMergeJobTemplates(t *testing.T) {
+	uid, otherUID, gid := int64(1000), int64(2000), int64(3000)
+	cpu := func(q string) core_v1.ResourceList {
+		return core_v1.ResourceList{core_v1.ResourceCPU: resource.MustParse(q)}
+	}
+	defaults := &v1alpha1.JobTemplate{
+		Resources:          core_v1.ResourceRequirements{Limits: cpu("1")},
+		NodeSelector:       map[string]string{"zone": "a", "disk": "ssd"},
+		PriorityClassName:  "low",
+		ServiceAccountName: "populator",
+		RunAsUser:          &uid,
+		ImagePullSecrets:   []core_v1.LocalObjectReference{{Name: "registry"}},
+	}
+	tests := []struct {
+		name               string
+		defaults, override *v1alpha1.JobTemplate
+		want               *v1alpha1.JobTemplate
+	}{
+		{"neither", nil, nil, nil},
+		{"defaults only", defaults, nil, defaults},
+		{"override only", nil, &v1alpha1.JobTemplate{PriorityClassName: "high"}, &v1alpha1.JobTemplate{PriorityClassName: "high"}},
+		{
+			name:     "override layered on defaults",
+			defaults: defaults,
+			override: &v1alpha1.JobTemplate{
+				Resources:        core_v1.ResourceRequirements{Limits: core_v1.ResourceList{core_v1.ResourceMemory: resource.MustParse("1Gi")}, Requests: cpu("100m")},
+				NodeSelector:     map[string]string{"zone": "b"},
+				RunAsUser:        &otherUID,
+				FSGroup:          &gid,
+				ImagePullSecrets: []core_v1.LocalObjectReference{{Name: "registry"}, {Name: "private"}},
+			},
+			want: &v1alpha1.JobTemplate{
+				Resources: core_v1.ResourceRequirements{
+					Limits:   core_v1.ResourceList{core_v1.ResourceCPU: resource.MustParse("1"), core_v1.ResourceMemory: resource.MustParse("1Gi")},
+					Requests: cpu("100m"),
+				},
+				NodeSelector:       map[string]string{"zone": "b", "disk": "ssd"},
+				PriorityClassName:  "low",
+				ServiceAccountName: "populator",
+				RunAsUser:          &otherUID,
+				FSGroup:            &gid,
+				ImagePullSecrets:   []core_v1.LocalObjectReference{{Name: "registry"}, {Name: "private"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		before := tt.defaults.DeepCopy()
+		got := MergeJobTemplates(tt.defaults, tt.override)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+		if !reflect.DeepEqual(tt.defaults, before) {
+			t.Errorf("%s: the defaults were modified", tt.name)
+		}
+	}
+}
+
+func TestBuildJobSpecTemplate(t *testing.T) {
+	root, uid, gid := int64(0), int64(1000), int64(2000)
+	tests := []struct {
+		name     string
+		template *v1alpha1.JobTemplate
+		check    func(*core_v1.PodSpec) bool
+	}{
+		{"no template", nil, func(pod *core_v1.PodSpec) bool {
+			return pod.SecurityContext.RunAsUser == nil && pod.SecurityContext.FSGroup == nil && pod.NodeSelector == nil
+		}},
+		{"scheduling", &v1alpha1.JobTemplate{NodeSelector: map[string]string{"zone": "a"}, PriorityClassName: "high"}, func(pod *core_v1.PodSpec) bool {
+			return pod.NodeSelector["zone"] == "a" && pod.PriorityClassName == "high"
+		}},
+		{"non-root user", &v1alpha1.JobTemplate{RunAsUser: &uid, FSGroup: &gid}, func(pod *core_v1.PodSpec) bool {
+			sc := pod.SecurityContext
+			return *sc.RunAsUser == uid && *sc.RunAsNonRoot && *sc.FSGroup == gid
+		}},
+		{"root user", &v1alpha1.JobTemplate{RunAsUser: &root}, func(pod *core_v1.PodSpec) bool {
+			return !*pod.SecurityContext.RunAsNonRoot
+		}},
+		{"resources", &v1alpha1.JobTemplate{Resources: core_v1.ResourceRequirements{Limits: core_v1.ResourceList{core_v1.ResourceCPU: resource.MustParse("1")}}}, func(pod *core_v1.PodSpec) bool {
+			return pod.Containers[0].Resources.Limits.Cpu().String() == "1"
+		}},
+	}
+	for _, tt := range tests {
+		job := BuildJobSpec(&JobRequest{Name: "job", Image: "img", PVCName: "pvc", MountPoint: "/data", Template: tt.template})
+		pod := &job.Spec.Template.Spec
+		if pod.SecurityContext == nil || pod.SecurityContext.SeccompProfile == nil {
+			t.Errorf("%s: the pod has no seccomp profile", tt.name)
+			continue
+		}
+		if !tt.check(pod) {
+			t.Errorf("%s: unexpected pod spec %+v", tt.name, pod)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package populator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	batch "k8s.io/api/batch/v1"
+	coordination "k8s.io/api/coordination/v1"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Only one job writes to a volume at a time.  Before launching a job createJob takes the PVC's Lease, naming the job
+// as its holder, and nobody else can take it until that job has finished.  Creating or updating the Lease is what
+// makes it safe, two handlers racing for the same PVC can't both succeed.  The Lease is owned by the PVC and goes
+// with it
+
+// PVCLockDuration is how long a Lease whose job doesn't exist is still held, it covers the time between taking the
+// Lease and creating the job
+var PVCLockDuration = 2 * time.Minute
+
+// LockedError is returned by the CreateJob functions when another job holds the PVC, the job can be launched once
+// it's finished
+type LockedError struct {
+	PVC string
+	Job string
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("PVC %s is locked by job %s", e.PVC, e.Job)
+}
+
+// pvcLockName is the name of the PVC's Lease, in its namespace
+func pvcLockName(pvc *core_v1.PersistentVolumeClaim) string {
+	return "populator-pvc-" + pvc.Name
+}
+
+// lockPVC takes the PVC's Lease for jobName.  A Lease already held by jobName is taken to be from an earlier attempt
+// to launch the same job
+func lockPVC(c kubernetes.Interface, pvc *core_v1.PersistentVolumeClaim, jobName string) error {
+	leases := c.CoordinationV1().Leases(pvc.Namespace)
+	now := metav1.NewMicroTime(time.Now())
+	seconds := int32(PVCLockDuration / time.Second)
+	lease := &coordination.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pvcLockName(pvc),
+			Namespace: pvc.Namespace,
+			Labels:    map[string]string{"app": "populator", v1alpha1.LabelPVC: pvc.Name},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "v1",
+				Kind:       "PersistentVolumeClaim",
+				Name:       pvc.Name,
+				UID:        pvc.UID,
+			}},
+		},
+		Spec: coordination.LeaseSpec{HolderIdentity: &jobName, LeaseDurationSeconds: &seconds, AcquireTime: &now, RenewTime: &now},
+	}
+	_, err := leases.Create(context.TODO(), lease, metav1.CreateOptions{})
+	if !errors.IsAlreadyExists(err) {
+		return err
+	}
+	existing, err := leases.Get(context.TODO(), lease.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	holder := ""
+	if existing.Spec.HolderIdentity != nil {
+		holder = *existing.Spec.HolderIdentity
+	}
+	if holder == jobName {
+		return nil
+	}
+	held, err := lockHeld(c, pvc.Namespace, existing)
+	if err != nil {
+		return err
+	}
+	if held {
+		return &LockedError{PVC: pvc.Name, Job: holder}
+	}
+	lease.ResourceVersion = existing.ResourceVersion
+	if _, err := leases.Update(context.TODO(), lease, metav1.UpdateOptions{}); errors.IsConflict(err) {
+		// somebody else took it in the meantime
+		return &LockedError{PVC: pvc.Name, Job: holder}
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// lockHeld returns true if the Lease's job is still running, or if it hasn't been created yet and the Lease hasn't
+// run out
+func lockHeld(c kubernetes.Interface, namespace string, lease *coordination.Lease) (bool, error) {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return false, nil
+	}
+	job, err := c.BatchV1().Jobs(namespace).Get(context.TODO(), *lease.Spec.HolderIdentity, metav1.GetOptions{})
+	if err == nil {
+		return !JobFinished(job), nil
+	}
+	if !errors.IsNotFound(err) {
+		return false, err
+	}
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return false, nil
+	}
+	expires := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return time.Now().Before(expires), nil
+}
+
+// unlockPVC gives up the PVC's Lease if jobName still holds it, for a job that couldn't be launched after all
+func unlockPVC(c kubernetes.Interface, pvc *core_v1.PersistentVolumeClaim, jobName string) {
+	leases := c.CoordinationV1().Leases(pvc.Namespace)
+	lease, err := leases.Get(context.TODO(), pvcLockName(pvc), metav1.GetOptions{})
+	if err != nil || lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != jobName {
+		return
+	}
+	// only the Lease we looked at, if it's changed hands since it isn't ours to delete
+	precondition := metav1.Preconditions{ResourceVersion: &lease.ResourceVersion}
+	if err := leases.Delete(context.TODO(), lease.Name, metav1.DeleteOptions{Preconditions: &precondition}); err != nil && !errors.IsNotFound(err) {
+		log.Printf("unable to release lock on PVC %s: %v", pvc.Name, err)
+	}
+}
+
+// JobFinished returns true once the job has completed or failed for good
+func JobFinished(job *batch.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batch.JobComplete || c.Type == batch.JobFailed) && c.Status == core_v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package populator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batch "k8s.io/api/batch/v1"
+	coordination "k8s.io/api/coordination/v1"
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLockPVC(t *testing.T) {
+	pvc := &core_v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default", UID: "1234"}}
+	lease := func(holder string, renewed time.Duration) *coordination.Lease {
+		renewTime := metav1.NewMicroTime(time.Now().Add(-renewed))
+		seconds := int32(PVCLockDuration / time.Second)
+		return &coordination.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: pvcLockName(pvc), Namespace: "default"},
+			Spec:       coordination.LeaseSpec{HolderIdentity: &holder, RenewTime: &renewTime, LeaseDurationSeconds: &seconds},
+		}
+	}
+	job := func(name string, finished bool) *batch.Job {
+		j := &batch.Job{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+		if finished {
+			j.Status.Conditions = []batch.JobCondition{{Type: batch.JobComplete, Status: core_v1.ConditionTrue}}
+		}
+		return j
+	}
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		locked  string // the job the PVC should be locked by, empty if we should get it
+	}{
+		{name: "no lease"},
+		{name: "ours already", objects: []runtime.Object{lease("ours", time.Hour)}},
+		{name: "job running", objects: []runtime.Object{lease("other", time.Hour), job("other", false)}, locked: "other"},
+		{name: "job finished", objects: []runtime.Object{lease("other", 0), job("other", true)}},
+		{name: "job about to be created", objects: []runtime.Object{lease("other", time.Second)}, locked: "other"},
+		{name: "job never created", objects: []runtime.Object{lease("other", PVCLockDuration+time.Second)}},
+		{name: "job gone", objects: []runtime.Object{lease("other", time.Hour)}},
+	}
+	for _, tt := range tests {
+		c := fake.NewClientset(tt.objects...)
+		err := lockPVC(c, pvc, "ours")
+		if tt.locked != "" {
+			if locked, ok := err.(*LockedError); !ok || locked.Job != tt.locked {
+				t.Errorf("%s: lockPVC() = %v, want locked by %s", tt.name, err, tt.locked)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: lockPVC() = %v", tt.name, err)
+			continue
+		}
+		got, err := c.CoordinationV1().Leases("default").Get(context.TODO(), pvcLockName(pvc), metav1.GetOptions{})
+		if err != nil || got.Spec.HolderIdentity == nil || *got.Spec.HolderIdentity != "ours" {
+			t.Errorf("%s: lease %+v (%v), want it held by ours", tt.name, got, err)
+		}
+	}
+}
+
+func TestUnlockPVC(t *testing.T) {
+	pvc := &core_v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"}}
+	c := fake.NewClientset()
+	if err := lockPVC(c, pvc, "ours"); err != nil {
+		t.Fatalf("lockPVC() = %v", err)
+	}
+	unlockPVC(c, pvc, "other")
+	if _, err := c.CoordinationV1().Leases("default").Get(context.TODO(), pvcLockName(pvc), metav1.GetOptions{}); err != nil {
+		t.Errorf("unlockPVC() gave up a lease held by another job: %v", err)
+	}
+	unlockPVC(c, pvc, "ours")
+	if _, err := c.CoordinationV1().Leases("default").Get(context.TODO(), pvcLockName(pvc), metav1.GetOptions{}); err == nil {
+		t.Errorf("unlockPVC() kept the lease")
+	}
+	if err := lockPVC(c, pvc, "other"); err != nil {
+		t.Errorf("lockPVC() after unlockPVC() = %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package populator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	core_v1 "k8s.io/api/core/v1"
+)
+
+// Every source step is asked to record what it wrote.  The manifest is a text file in ManifestDir (relative to the
+// mountpoint) named <step>.manifest: two header lines, "# source: <url>" and "# revision: <commit, etag...>",
+// followed by one "<sha256> <size> <path>" line per file, sorted by path.  The step then writes a StepResult as
+// JSON to its termination message so the controller can record it without reading the volume.
+const (
+	ManifestDir = ".populator"
+
+	// EnvManifestDir, EnvStep and EnvStepType are set on every source step, images that don't support manifests
+	// can ignore them
+	EnvManifestDir = "POPULATOR_MANIFEST_DIR"
+	EnvStep        = "POPULATOR_STEP"
+	EnvStepType    = "POPULATOR_STEP_TYPE"
+)
+
+// StepResult is the summary a source step reports in its termination message
+type StepResult struct {
+	Step           string `json:"step"`
+	Revision       string `json:"revision,omitempty"`
+	ManifestDigest string `json:"manifestDigest"`
+	Files          int64  `json:"files"`
+	Bytes          int64  `json:"bytes"`
+}
+
+// manifestEnv returns the environment telling a source step where to write its manifest
+func manifestEnv(mountpoint, step, sourceType string) []core_v1.EnvVar {
+	return []core_v1.EnvVar{
+		{Name: EnvManifestDir, Value: path.Join(v1alpha1.NormalizeMountpoint(mountpoint), ManifestDir)},
+		{Name: EnvStep, Value: step},
+		{Name: EnvStepType, Value: sourceType},
+	}
+}
+
+// ParseStepResult decodes a step's termination message, steps that don't report a manifest return nil
+func ParseStepResult(message string) (*StepResult, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil, nil
+	}
+	r := &StepResult{}
+	if err := json.Unmarshal([]byte(message), r); err != nil {
+		return nil, fmt.Errorf("invalid populator step result: %v", err)
+	}
+	if r.ManifestDigest == "" {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// StepResultsFromPod collects the results reported by the source steps of a finished populator pod, each step's
+// termination message is decoded by its type
+func StepResultsFromPod(pod *core_v1.Pod) ([]StepResult, error) {
+	var results []StepResult
+	containers := map[string]core_v1.Container{}
+	for _, c := range append(append([]core_v1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		containers[c.Name] = c
+	}
+	statuses := append([]core_v1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		if cs.State.Terminated == nil {
+			continue
+		}
+		parse := ParseStepResult
+		if t, ok := typeOfStep(containers[cs.Name]); ok {
+			parse = t.ParseResult
+		}
+		r, err := parse(cs.State.Terminated.Message)
+		if err != nil {
+			return nil, fmt.Errorf("step %s: %v", cs.Name, err)
+		}
+		if r != nil {
+			results = append(results, *r)
+		}
+	}
+	return results, nil
+}
+
+// ContentDigest combines the manifest digests of every step into a single digest for the whole volume, the
+// steps are sorted first so the digest only depends on what was written
+func ContentDigest(results []StepResult) string {
+	lines := make([]string, 0, len(results))
+	for _, r := range results {
+		lines = append(lines, r.Step+" "+r.ManifestDigest)
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,93 @@
+package populator
+
+import (
+	"reflect"
+	"testing"
+
+	core_v1 "k8s.io/api/core/v1"
+)
+
+func TestParseStepResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    *StepResult
+		wantErr bool
+	}{
+		{name: "no message"},
+		{name: "whitespace", message: " \n"},
+		{
+			name:    "full result",
+			message: `{"step":"git","revision":"abc123","manifestDigest":"sha256:00ff","files":3,"bytes":1024}` + "\n",
+			want:    &StepResult{Step: "git", Revision: "abc123", ManifestDigest: "sha256:00ff", Files: 3, Bytes: 1024},
+		},
+		{
+			name:    "no revision",
+			message: `{"step":"s3","manifestDigest":"sha256:00ff","files":1,"bytes":1}`,
+			want:    &StepResult{Step: "s3", ManifestDigest: "sha256:00ff", Files: 1, Bytes: 1},
+		},
+		{name: "no manifest digest", message: `{"step":"git","files":3}`},
+		{name: "not json", message: "clone failed: repository not found", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseStepResult(tt.message)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStepResultsFromPod(t *testing.T) {
+	terminated := func(name, message string) core_v1.ContainerStatus {
+		return core_v1.ContainerStatus{Name: name, State: core_v1.ContainerState{Terminated: &core_v1.ContainerStateTerminated{Message: message}}}
+	}
+	pod := &core_v1.Pod{Status: core_v1.PodStatus{
+		InitContainerStatuses: []core_v1.ContainerStatus{
+			terminated("git", `{"step":"git","manifestDigest":"sha256:aa"}`),
+			terminated("set-ownership", ""),
+		},
+		ContainerStatuses: []core_v1.ContainerStatus{
+			terminated("s3", `{"step":"s3","manifestDigest":"sha256:bb"}`),
+			{Name: "still-running"},
+		},
+	}}
+	results, err := StepResultsFromPod(pod)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []StepResult{{Step: "git", ManifestDigest: "sha256:aa"}, {Step: "s3", ManifestDigest: "sha256:bb"}}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("got %+v, want %+v", results, want)
+	}
+
+	pod.Status.ContainerStatuses[0] = terminated("s3", "{broken")
+	if _, err := StepResultsFromPod(pod); err == nil {
+		t.Errorf("a broken termination message wasn't reported")
+	}
+}
+
+func TestContentDigest(t *testing.T) {
+	a := StepResult{Step: "git", ManifestDigest: "sha256:aa"}
+	b := StepResult{Step: "s3", ManifestDigest: "sha256:bb"}
+	tests := []struct {
+		name  string
+		x, y  []StepResult
+		equal bool
+	}{
+		{"step order doesn't matter", []StepResult{a, b}, []StepResult{b, a}, true},
+		{"sizes and revisions don't matter", []StepResult{a}, []StepResult{{Step: "git", ManifestDigest: "sha256:aa", Revision: "abc", Files: 9}}, true},
+		{"content does", []StepResult{a}, []StepResult{{Step: "git", ManifestDigest: "sha256:cc"}}, false},
+		{"step names do", []StepResult{a}, []StepResult{{Step: "other", ManifestDigest: "sha256:aa"}}, false},
+		{"missing steps do", []StepResult{a, b}, []StepResult{a}, false},
+	}
+	for _, tt := range tests {
+		x, y := ContentDigest(tt.x), ContentDigest(tt.y)
+		if (x == y) != tt.equal {
+			t.Errorf("%s: digests %s and %s, want equal %v", tt.name, x, y, tt.equal)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package populator
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	core_v1 "k8s.io/api/core/v1"
+)
+
+// OwnershipImage is the image used for the chown/chmod step, it only needs a shell and coreutils.  It's the same
+// pinned busybox as cache eviction
+const OwnershipImage = CacheEvictionImage
+
+// ownershipStep returns the container that fixes up ownership and permissions of the populated data, or nil if
+// there's nothing for it to do.  GID on its own is handled by fsGroup and doesn't need a step
+func ownershipStep(r *JobRequest, mounts []core_v1.VolumeMount) *core_v1.Container {
+	o := r.Ownership
+	if o == nil || (o.UID == nil && o.Mode == "") {
+		return nil
+	}
+
+	// the owner, mode and directory are passed as positional parameters rather than pasted into the script
+	script := ""
+	owner := ""
+	if o.UID != nil {
+		owner = strconv.FormatInt(*o.UID, 10)
+		if o.GID != nil {
+			owner += ":" + strconv.FormatInt(*o.GID, 10)
+		}
+		script = `chown -R "$1" "$3"`
+	}
+	if o.Mode != "" {
+		if script != "" {
+			script += " && "
+		}
+		script += `chmod -R "$2" "$3"`
+	}
+
+	// chown needs root and CAP_CHOWN, and chmod of files we don't own needs CAP_FOWNER, so this is the one
+	// step that can't run under the restricted pod security level.  Both capabilities are allowed by baseline, the
+	// namespace the job runs in has to enforce that or nothing (see the README)
+	sc := restrictedSecurityContext()
+	sc.Capabilities.Add = []core_v1.Capability{"CHOWN", "FOWNER"}
+	root := int64(0)
+	notNonRoot := false
+	sc.RunAsUser = &root
+	sc.RunAsNonRoot = &notNonRoot
+
+	return &core_v1.Container{
+		Name:            "set-ownership",
+		Image:           OwnershipImage,
+		Command:         []string{"sh", "-c", script, "--", owner, o.Mode, v1alpha1.NormalizeMountpoint(r.MountPoint)},
+		SecurityContext: sc,
+		VolumeMounts:    mounts,
+	}
+}
+
+// checkOwnership makes sure the requested GID and the job template's fsGroup don't disagree, one of them would
+// otherwise be quietly ignored
+func checkOwnership(o *v1alpha1.Ownership, t *v1alpha1.JobTemplate) error {
+	if o == nil || o.GID == nil || t == nil || t.FSGroup == nil || *o.GID == *t.FSGroup {
+		return nil
+	}
+	return fmt.Errorf("ownership gid %d conflicts with the job template's fs_group %d", *o.GID, *t.FSGroup)
+}
+
+// applyOwnership sets fsGroup from the requested GID so the volume is group owned (and group writable for the
+// volume types that support it), checkOwnership has already made sure the job template doesn't want another one
+func applyOwnership(pod *core_v1.PodSpec, o *v1alpha1.Ownership) {
+	if o == nil || o.GID == nil {
+		return
+	}
+	gid := *o.GID
+	pod.SecurityContext.FSGroup = &gid
+}
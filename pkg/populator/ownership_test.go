@@ -0,0 +1,102 @@
+package populator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+)
+
+func TestOwnershipStep(t *testing.T) {
+	uid, gid, templateGID := int64(1000), int64(2000), int64(3000)
+	tests := []struct {
+		name      string
+		ownership *v1alpha1.Ownership
+		template  *v1alpha1.JobTemplate
+		command   []string
+		fsGroup   *int64
+	}{
+		{name: "no ownership"},
+		{name: "gid only uses fsGroup", ownership: &v1alpha1.Ownership{GID: &gid}, fsGroup: &gid},
+		{
+			name:      "uid",
+			ownership: &v1alpha1.Ownership{UID: &uid},
+			command:   []string{"sh", "-c", `chown -R "$1" "$3"`, "--", "1000", "", "/data"},
+		},
+		{
+			name:      "uid and gid",
+			ownership: &v1alpha1.Ownership{UID: &uid, GID: &gid},
+			command:   []string{"sh", "-c", `chown -R "$1" "$3"`, "--", "1000:2000", "", "/data"},
+			fsGroup:   &gid,
+		},
+		{
+			name:      "mode",
+			ownership: &v1alpha1.Ownership{Mode: "0750"},
+			command:   []string{"sh", "-c", `chmod -R "$2" "$3"`, "--", "", "0750", "/data"},
+		},
+		{
+			name:      "uid and mode",
+			ownership: &v1alpha1.Ownership{UID: &uid, Mode: "u+rwX"},
+			command:   []string{"sh", "-c", `chown -R "$1" "$3" && chmod -R "$2" "$3"`, "--", "1000", "u+rwX", "/data"},
+		},
+		{
+			name:      "job template fsGroup the same as gid",
+			ownership: &v1alpha1.Ownership{GID: &gid},
+			template:  &v1alpha1.JobTemplate{FSGroup: &gid},
+			fsGroup:   &gid,
+		},
+		{
+			name:     "job template fsGroup without ownership",
+			template: &v1alpha1.JobTemplate{FSGroup: &templateGID},
+			fsGroup:  &templateGID,
+		},
+	}
+	for _, tt := range tests {
+		job := BuildJobSpec(&JobRequest{Name: "job", Image: "img", PVCName: "pvc", MountPoint: "/data", Ownership: tt.ownership, Template: tt.template})
+		pod := job.Spec.Template.Spec
+		steps := append(pod.InitContainers, pod.Containers...)
+
+		if tt.command == nil {
+			if len(steps) != 1 {
+				t.Errorf("%s: got %d steps, want just the populator", tt.name, len(steps))
+			}
+		} else {
+			if len(steps) != 2 {
+				t.Errorf("%s: got %d steps, want the populator and the ownership step", tt.name, len(steps))
+				continue
+			}
+			step := steps[1]
+			if !reflect.DeepEqual(step.Command, tt.command) {
+				t.Errorf("%s: command %q, want %q", tt.name, step.Command, tt.command)
+			}
+			if step.Image != OwnershipImage || *step.SecurityContext.RunAsUser != 0 || *step.SecurityContext.AllowPrivilegeEscalation {
+				t.Errorf("%s: unexpected ownership step %+v", tt.name, step)
+			}
+		}
+		if !reflect.DeepEqual(pod.SecurityContext.FSGroup, tt.fsGroup) {
+			t.Errorf("%s: fsGroup %v, want %v", tt.name, pod.SecurityContext.FSGroup, tt.fsGroup)
+		}
+	}
+}
+
+func TestCheckOwnership(t *testing.T) {
+	gid, otherGID := int64(2000), int64(3000)
+	tests := []struct {
+		name      string
+		ownership *v1alpha1.Ownership
+		template  *v1alpha1.JobTemplate
+		ok        bool
+	}{
+		{"nothing set", nil, nil, true},
+		{"gid only", &v1alpha1.Ownership{GID: &gid}, nil, true},
+		{"fsGroup only", nil, &v1alpha1.JobTemplate{FSGroup: &gid}, true},
+		{"the same group", &v1alpha1.Ownership{GID: &gid}, &v1alpha1.JobTemplate{FSGroup: &gid}, true},
+		{"different groups", &v1alpha1.Ownership{GID: &gid}, &v1alpha1.JobTemplate{FSGroup: &otherGID}, false},
+	}
+	for _, tt := range tests {
+		err := checkOwnership(tt.ownership, tt.template)
+		if ok := err == nil; ok != tt.ok {
+			t.Errorf("%s: checkOwnership() = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
@@ -1,22 +1,47 @@
 package populator
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
 	batch "k8s.io/api/batch/v1"
 	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/kubernetes"
 )
 
 // GitPopulatorImage is the provided container image to handle git population
 // the default git populator is pretty simple, it's entrypoint is a simple script
 // to clone <branch> <repo> <destination-folder>
-const GitPopulatorImage = "jgriffith/git-populator"
+const GitPopulatorImage = v1alpha1.DefaultGitImage
 
-var ttl = int32(30) // our default ttl for completed containers is 30 seconds
+// Controller wide defaults for the job lifecycle, used when neither the JobRequest nor the Populator sets them.
+// The manager exposes these as flags
+var (
+	DefaultTTLSecondsAfterFinished = v1alpha1.DefaultTTLSecondsAfterFinished // how long completed jobs stick around
+	DefaultBackoffLimit            = v1alpha1.DefaultBackoffLimit            // retries before we give up on a population
+	DefaultActiveDeadlineSeconds   = v1alpha1.DefaultActiveDeadlineSeconds   // overall time limit for a population
+)
+
+// JobStep is a single container in a populator job, a job with more than one step runs them in order
+type JobStep struct {
+	Name       string
+	Image      string
+	Command    []string // Optional, defaults to the image's entrypoint
+	Args       []string
+	WorkingDir string // Optional
+	Env        []core_v1.EnvVar
+	// UseCache mounts the download cache into the step, if there is one
+	UseCache bool
+	// Secret is the secret SecretKeys are read from, see SecretKey.  The caller fills it in from the source's
+	// secret_ref, types only say which keys they need
+	Secret     string
+	SecretKeys []SecretKey
+}
 
 // JobRequest encapsulates all the details we need to run a populator job
 type JobRequest struct {
@@ -25,89 +50,424 @@ type JobRequest struct {
 	Args       []string
 	MountPoint string
 	PVCName    string
+	// Steps is optional, if it's set it replaces Image and Args and each step is run in turn (used when a
+	// Populator has more than one source)
+	Steps []JobStep
+	// PopulatorName is optional, it's only used to label the job
+	PopulatorName string
+	// TTLSecondsAfterFinished, BackoffLimit and ActiveDeadlineSeconds are optional, if they're nil we use the
+	// controller wide defaults
+	TTLSecondsAfterFinished *int32
+	BackoffLimit            *int32
+	ActiveDeadlineSeconds   *int64
+	// Template is optional pod level configuration (resources, scheduling, security) for the job
+	Template *v1alpha1.JobTemplate
+	// Ownership is optional, it sets who owns the populated data and its permissions once population is done
+	Ownership *v1alpha1.Ownership
+	// DownloadCacheClaim is optional, it defaults to DefaultDownloadCacheClaim.  NoDownloadCache leaves the cache
+	// out of the job altogether
+	DownloadCacheClaim string
+	NoDownloadCache    bool
+	// RequestName is optional, it labels the job with the PopulationRequest it's running
+	RequestName string
 }
 
 // CreateJobFromObjects is a helper function to take a pvc and a populator object and set up a JobRequest that caller can then use to launch the populator job.
-// For users that want to roll their own JobRequst and call RunPopulatorJob directly they can ignore this function
-func CreateJobFromObjects(c kubernetes.Interface, pvc *core_v1.PersistentVolumeClaim, p *v1alpha1.Populator) (*batch.Job, error) {
-	var job *batch.Job
-	req := &JobRequest{}
-
-	switch p.Spec.Type {
-	case "git":
-		log.Printf("creating job for git-populator: %v", p.Spec)
-		req = &JobRequest{
-			Name:       p.GetObjectMeta().GetName() + "-pvc-" + pvc.Name,
-			Image:      GitPopulatorImage,
-			MountPoint: p.Spec.Mountpoint,
-			PVCName:    pvc.Name,
-			Args:       []string{p.Spec.Git.Repo, p.Spec.Git.Branch, p.Spec.Mountpoint},
+// For users that want to roll their own JobRequst and call RunPopulatorJob directly they can ignore this function.
+// defaults is the controller wide job template (it may be nil), the Populator's own jobTemplate is merged on top of it
+func CreateJobFromObjects(c kubernetes.Interface, pvc *core_v1.PersistentVolumeClaim, p *v1alpha1.Populator, defaults *v1alpha1.JobTemplate) (*batch.Job, error) {
+	return CreateJobFromPopulators(c, pvc, []*v1alpha1.Populator{p}, defaults)
+}
+
+// CreateJobFromPopulators is CreateJobFromObjects for a Populator with includes, pops is the list returned by
+// ResolveIncludes.  Every Populator's sources are populated in order by a single job, the job settings (mountpoint,
+// retries, template, ownership) come from the last Populator, the one the PVC actually asked for
+func CreateJobFromPopulators(c kubernetes.Interface, pvc *core_v1.PersistentVolumeClaim, pops []*v1alpha1.Populator, defaults *v1alpha1.JobTemplate) (*batch.Job, error) {
+	return createJob(c, pvc, pops, defaults, jobOptions{})
+}
+
+// CreateRefreshJobFromPopulators is CreateJobFromPopulators for a PVC that's already been populated, the sources
+// are updated in place (e.g. a git fetch rather than a clone) instead of being written from scratch.  token
+// identifies the refresh request, it keeps the job name unique
+func CreateRefreshJobFromPopulators(c kubernetes.Interface, pvc *core_v1.PersistentVolumeClaim, pops []*v1alpha1.Populator, defaults *v1alpha1.JobTemplate, token string) (*batch.Job, error) {
+	if token == "" {
+		return nil, fmt.Errorf("a refresh of PVC %s needs a token", pvc.Name)
+	}
+	return createJob(c, pvc, pops, defaults, jobOptions{refresh: true, nameSuffix: "-refresh-" + shortHash(token)})
+}
+
+// CreateJobFromRequest launches the job for a PopulationRequest, pops is the list ResolveIncludes returned for the
+// requested Populator.  The request's overrides are applied to the last of them (on top of any the PVC sets), as
+// long as it lists them as Overridable, and since the PVC may well hold data already, the sources are updated in place like a refresh
+func CreateJobFromRequest(c kubernetes.Interface, pvc *core_v1.PersistentVolumeClaim, pops []*v1alpha1.Populator, defaults *v1alpha1.JobTemplate, r *v1alpha1.PopulationRequest) (*batch.Job, error) {
+	return createJob(c, pvc, pops, defaults, jobOptions{
+		refresh:    true,
+		nameSuffix: "-request-" + shortHash(string(r.UID)),
+		request:    r.Name,
+		overrides:  r.Spec.Overrides,
+	})
+}
+
+// jobOptions are the ways a job from createJob can differ from a PVC's first population
+type jobOptions struct {
+	refresh    bool                // update the sources in place
+	nameSuffix string              // keeps the job name unique when the PVC has been populated before
+	request    string              // the PopulationRequest the job is for, if any
+	overrides  *v1alpha1.Overrides // applied after the PVC's own, only if the Populator lists them as Overridable
+}
+
+// ResolvePopulators returns pops the way the job for pvc populates them: templates rendered for the PVC, then its
+// annotation overrides and extra applied.  pops themselves aren't changed, the result lines up with them one for one
+func ResolvePopulators(pvc *core_v1.PersistentVolumeClaim, pops []*v1alpha1.Populator, extra *v1alpha1.Overrides) ([]*v1alpha1.Populator, error) {
+	// templates are rendered before the overrides are applied, an override is taken as it is
+	pops, err := renderPopulators(pvc, pops)
+	if err != nil {
+		return nil, err
+	}
+	return withOverrides(pvc, pops, extra)
+}
+
+// withOverrides returns pops with the PVC's annotation overrides, and then extra, applied to a copy of the last
+// of them (the Populator the PVC asked for).  Only the fields that Populator lists as Overridable may be set, by
+// the PVC or by extra
+func withOverrides(pvc *core_v1.PersistentVolumeClaim, pops []*v1alpha1.Populator, extra *v1alpha1.Overrides) ([]*v1alpha1.Populator, error) {
+	fromPVC := v1alpha1.OverridesFromAnnotations(pvc.Annotations)
+	if fromPVC == nil && extra == nil {
+		return pops, nil
+	}
+	pop := pops[len(pops)-1].DeepCopy()
+	if fromPVC != nil {
+		if err := pop.Spec.CheckOverridable(fromPVC); err != nil {
+			return nil, fmt.Errorf("PVC %s annotations: %v", pvc.Name, err)
+		}
+		if err := fromPVC.Apply(&pop.Spec); err != nil {
+			return nil, fmt.Errorf("PVC %s annotations: %v", pvc.Name, err)
+		}
+	}
+	if extra != nil {
+		if err := pop.Spec.CheckOverridable(extra); err != nil {
+			return nil, fmt.Errorf("Populator %s: %v", pop.Name, err)
+		}
+		if err := extra.Apply(&pop.Spec); err != nil {
+			return nil, fmt.Errorf("unable to apply overrides to Populator %s: %v", pop.Name, err)
+		}
+	}
+	if errs := v1alpha1.ValidateSubpaths(pop.Spec.AllSources(), field.NewPath("spec", "sources")); len(errs) > 0 {
+		return nil, fmt.Errorf("Populator %s with the overrides for PVC %s: %v", pop.Name, pvc.Name, errs.ToAggregate())
+	}
+	return append(append([]*v1alpha1.Populator{}, pops[:len(pops)-1]...), pop), nil
+}
+
+// InvalidError is returned by the CreateJob functions when the Populators can't be made into a job for the PVC: a
+// template that won't render for it, an override it isn't allowed, a source that doesn't validate.  Trying again
+// won't help until one of them changes, unlike a failure to create the job
+type InvalidError struct {
+	Err error
+}
+
+func (e *InvalidError) Error() string {
+	return e.Err.Error()
+}
+
+// createJob builds and launches the job for the CreateJob functions.  A job that already exists is taken to be this
+// one, launched by an earlier attempt that didn't get as far as recording it (the name is the same for the same
+// PVC and request).  The job isn't launched while another holds the PVC (see lockPVC), a LockedError says which
+func createJob(c kubernetes.Interface, pvc *core_v1.PersistentVolumeClaim, pops []*v1alpha1.Populator, defaults *v1alpha1.JobTemplate, opts jobOptions) (*batch.Job, error) {
+	job, copies, err := buildJob(pvc, pops, defaults, opts)
+	if err != nil {
+		return nil, &InvalidError{Err: err}
+	}
+	if err := lockPVC(c, pvc, job.Name); err != nil {
+		return nil, err
+	}
+	created, err := RunPopulatorJob(c, job, pvc.Namespace)
+	if errors.IsAlreadyExists(err) {
+		// the earlier attempt either copied its secrets or deleted the job again
+		job.Namespace = pvc.Namespace
+		return job, nil
+	}
+	if err != nil {
+		unlockPVC(c, pvc, job.Name)
+		return nil, err
+	}
+	if len(copies) == 0 {
+		return created, nil
+	}
+	if err := copySecrets(c, created, copies); err != nil {
+		// the job would never get going without them
+		propagation := metav1.DeletePropagationBackground
+		if err := c.BatchV1().Jobs(created.Namespace).Delete(context.TODO(), created.Name, metav1.DeleteOptions{PropagationPolicy: &propagation}); err != nil {
+			log.Printf("unable to delete job %s: %v", created.Name, err)
+		}
+		unlockPVC(c, pvc, job.Name)
+		return nil, err
+	}
+	return created, nil
+}
+
+// buildJob works out the job createJob launches and the ClusterPopulator secrets it needs copied into its namespace
+func buildJob(pvc *core_v1.PersistentVolumeClaim, pops []*v1alpha1.Populator, defaults *v1alpha1.JobTemplate, opts jobOptions) (*batch.Job, []*secretCopy, error) {
+	if len(pops) == 0 {
+		return nil, nil, fmt.Errorf("no Populators to run for PVC %s", pvc.Name)
+	}
+	pops, err := ResolvePopulators(pvc, pops, opts.overrides)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the webhook should already have done this, but we don't require the webhook to be deployed
+	p := pops[len(pops)-1].DeepCopy()
+	p.Default()
+
+	req := &JobRequest{
+		Name:                    p.GetObjectMeta().GetName() + "-pvc-" + pvc.Name,
+		MountPoint:              p.Spec.Mountpoint,
+		PVCName:                 pvc.Name,
+		PopulatorName:           p.Name,
+		TTLSecondsAfterFinished: p.Spec.TTLSecondsAfterFinished,
+		BackoffLimit:            p.Spec.BackoffLimit,
+		ActiveDeadlineSeconds:   p.Spec.ActiveDeadlineSeconds,
+		Template:                MergeJobTemplates(defaults, p.Spec.JobTemplate),
+		Ownership:               p.Spec.Ownership,
+		RequestName:             opts.request,
+		NoDownloadCache:         pvc.Namespace != DownloadCacheNamespace,
+	}
+	req.Name += opts.nameSuffix
+	// the Populator's own template is checked by validation, this catches the controller wide one
+	if err := checkOwnership(req.Ownership, req.Template); err != nil {
+		return nil, nil, err
+	}
+	var copies []*secretCopy
+	for _, pop := range pops {
+		pop = pop.DeepCopy()
+		pop.Default()
+		// a ClusterPopulator's secret isn't in the PVC's namespace, the job uses its own copy
+		secretRef := pop.Spec.SecretRef
+		if pop.IsCluster() && secretRef != "" {
+			secretRef = clusterSecretName(req.Name, pop.Spec.SecretRef)
+			copies = append(copies, &secretCopy{from: pop.Spec.SecretRef, to: secretRef})
+		}
+		for i, src := range pop.Spec.AllSources() {
+			step, err := buildSourceStep(&src, p.Spec.Mountpoint, StepOptions{
+				SecretRef: secretRef,
+				CacheDir:  req.downloadCacheDir(),
+				Refresh:   opts.refresh,
+				Path:      sourcePath(&pop.Spec, i),
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("Populator %s: %v", pop.Name, err)
+			}
+			// type names can be anything a PopulatorClass can be called, dots included, so they don't go in the
+			// container name.  The type is recorded in the step's environment instead (see typeOfStep)
+			step.Name = fmt.Sprintf("step-%d", len(req.Steps))
+			if len(step.SecretKeys) > 0 {
+				step.Secret = secretRef
+			}
+			if req.downloadCacheDir() != "" {
+				step.Env = append(step.Env, core_v1.EnvVar{Name: EnvCacheScope, Value: cacheScope(pvc.Namespace, step.Secret)})
+			}
+			step.Env = append(step.Env, manifestEnv(p.Spec.Mountpoint, step.Name, src.Type)...)
+			if opts.refresh {
+				step.Env = append(step.Env, core_v1.EnvVar{Name: EnvRefresh, Value: "true"})
+			}
+			step.UseCache = true
+			req.Steps = append(req.Steps, *step)
 		}
-		job = BuildJobSpec(req)
-	case "s3":
-		log.Printf("Sorry, not implemented yet")
-	default:
-		log.Printf("sorry, I don't know what to do with the type: %s", p.Spec.Type)
-		return nil, fmt.Errorf("unknown Populator Type (%s)", p.Spec.Type)
+		// an included Populator's hook runs once its own sources are in, before anything that builds on it
+		if h := pop.Spec.PostPopulate; h != nil {
+			req.Steps = append(req.Steps, JobStep{
+				Name:       fmt.Sprintf("post-populate-%d", len(req.Steps)),
+				Image:      h.Image,
+				Command:    h.Command,
+				Args:       h.Args,
+				WorkingDir: v1alpha1.NormalizeMountpoint(p.Spec.Mountpoint),
+			})
+		}
+	}
+	if len(req.Steps) == 0 {
+		return nil, nil, fmt.Errorf("Populator %s has no sources to populate", p.Name)
+	}
+	for _, cp := range copies {
+		cp.keys = secretKeysUsed(req.Steps, cp.to)
+	}
+	return BuildJobSpec(req), copies, nil
+}
+
+// buildSourceStep hands a single source to its registered type to work out the step populating it into its
+// subpath of the mountpoint, opts.Dest is filled in here
+func buildSourceStep(src *v1alpha1.Source, mountpoint string, opts StepOptions) (*JobStep, error) {
+	t, ok := LookupType(src.Type)
+	if !ok {
+		log.Printf("sorry, I don't know what to do with the type: %s", src.Type)
+		return nil, fmt.Errorf("unknown Populator Type (%s)", src.Type)
 	}
+	opts.Dest = v1alpha1.SourcePath(mountpoint, src.Subpath)
+	return t.BuildStep(src, opts)
+}
+
+// downloadCacheClaim returns the download cache PVC the job uses, or an empty string if there isn't one
+func (r *JobRequest) downloadCacheClaim() string {
+	if r.NoDownloadCache {
+		return ""
+	}
+	if r.DownloadCacheClaim != "" {
+		return r.DownloadCacheClaim
+	}
+	return DefaultDownloadCacheClaim
+}
 
-	job = BuildJobSpec(req)
-	return RunPopulatorJob(c, job, pvc.Namespace)
+// downloadCacheDir returns where the download cache is mounted in the job, or an empty string if there isn't one
+func (r *JobRequest) downloadCacheDir() string {
+	if r.downloadCacheClaim() == "" {
+		return ""
+	}
+	return DownloadCacheMountPath
 }
 
 // BuildJobSpec takes a JobRequest and uses it to build a jobSpec, and launch the job.  We return the name of the Job to the caller
 // The aim here is to have a pretty generic template for the various types of populators, and we can just differentiate by the image
 // specified and the args supplied, we also make this public so users can choose to call it without using a formal populator object
 func BuildJobSpec(r *JobRequest) *batch.Job {
+	jobTTL := DefaultTTLSecondsAfterFinished
+	if r.TTLSecondsAfterFinished != nil {
+		jobTTL = *r.TTLSecondsAfterFinished
+	}
+	backoffLimit := DefaultBackoffLimit
+	if r.BackoffLimit != nil {
+		backoffLimit = *r.BackoffLimit
+	}
+	activeDeadline := DefaultActiveDeadlineSeconds
+	if r.ActiveDeadlineSeconds != nil {
+		activeDeadline = *r.ActiveDeadlineSeconds
+	}
+	labels := map[string]string{
+		"app":             "populator",
+		v1alpha1.LabelPVC: r.PVCName,
+	}
+	if r.PopulatorName != "" {
+		labels[v1alpha1.LabelPopulator] = r.PopulatorName
+	}
+	if r.RequestName != "" {
+		labels[v1alpha1.LabelRequest] = r.RequestName
+	}
+	volumeMounts := []core_v1.VolumeMount{
+		{
+			Name:      r.PVCName,
+			MountPath: v1alpha1.NormalizeMountpoint(r.MountPoint),
+		},
+	}
+
+	// the populator (one step per source) comes first, anything that has to happen once the data has landed
+	// follows it
+	jobSteps := r.Steps
+	if len(jobSteps) == 0 {
+		jobSteps = []JobStep{{Name: r.Name, Image: r.Image, Args: r.Args}}
+	}
+	volumes := []core_v1.Volume{
+		{
+			Name: r.PVCName,
+			VolumeSource: core_v1.VolumeSource{
+				PersistentVolumeClaim: &core_v1.PersistentVolumeClaimVolumeSource{
+					ClaimName: r.PVCName,
+					ReadOnly:  false,
+				},
+			},
+		},
+	}
+	cacheClaim := r.downloadCacheClaim()
+	var cacheMount core_v1.VolumeMount
+	if cacheClaim != "" {
+		var cacheVolume core_v1.Volume
+		cacheVolume, cacheMount = downloadCacheMount(cacheClaim)
+		volumes = append(volumes, cacheVolume)
+	}
+
+	var steps []core_v1.Container
+	for i, s := range jobSteps {
+		c := core_v1.Container{
+			Name:            s.Name,
+			Image:           s.Image,
+			Command:         s.Command,
+			Args:            s.Args,
+			WorkingDir:      s.WorkingDir,
+			Env:             s.Env,
+			SecurityContext: restrictedSecurityContext(),
+			VolumeMounts:    volumeMounts,
+		}
+		// only the source steps fetch anything, hooks don't get to see (or trash) the cache
+		if s.UseCache && cacheClaim != "" {
+			c.VolumeMounts = append([]core_v1.VolumeMount{cacheMount}, volumeMounts...)
+			c.Env = append(c.Env, core_v1.EnvVar{Name: EnvCacheDir, Value: DownloadCacheMountPath})
+		}
+		// each step only sees the secret keys it asked for
+		env, secretVolume := stepSecrets(s, fmt.Sprintf("populator-secrets-%d", i))
+		c.Env = append(c.Env, env...)
+		if secretVolume != nil {
+			volumes = append(volumes, *secretVolume)
+			c.VolumeMounts = append(append([]core_v1.VolumeMount{}, c.VolumeMounts...), core_v1.VolumeMount{
+				Name:      secretVolume.Name,
+				MountPath: SecretMountPath,
+				ReadOnly:  true,
+			})
+			c.Env = append(c.Env, core_v1.EnvVar{Name: EnvSecretDir, Value: SecretMountPath})
+		}
+		steps = append(steps, c)
+	}
+	if step := ownershipStep(r, volumeMounts); step != nil {
+		steps = append(steps, *step)
+	}
+
 	job := &batch.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: r.Name,
+			Name:   r.Name,
+			Labels: labels,
 		},
 		Spec: batch.JobSpec{
-			TTLSecondsAfterFinished: &ttl,
+			TTLSecondsAfterFinished: &jobTTL,
+			BackoffLimit:            &backoffLimit,
+			ActiveDeadlineSeconds:   &activeDeadline,
 			Template: core_v1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"app": "populator",
-					},
+					Labels: labels,
 				},
 				Spec: core_v1.PodSpec{
-					Containers: []core_v1.Container{
-						{
-							Name:  r.Name,
-							Image: r.Image,
-							Args:  r.Args,
-							VolumeMounts: []core_v1.VolumeMount{
-								{
-									Name:      r.PVCName,
-									MountPath: "/" + r.MountPoint,
-								},
-							},
-						},
-					},
-					RestartPolicy: core_v1.RestartPolicyOnFailure,
-					Volumes: []core_v1.Volume{
-						{
-							Name: r.PVCName,
-							VolumeSource: core_v1.VolumeSource{
-								PersistentVolumeClaim: &core_v1.PersistentVolumeClaimVolumeSource{
-									ClaimName: r.PVCName,
-									ReadOnly:  false,
-								},
-							},
+					// init containers run one at a time and in order, so every step but the last is one,
+					// if any step fails the pod fails
+					InitContainers: steps[:len(steps)-1],
+					Containers:     steps[len(steps)-1:],
+					// failed pods are kept (until the TTL) so their logs can be inspected, and the job
+					// controller counts each one against BackoffLimit
+					RestartPolicy: core_v1.RestartPolicyNever,
+					SecurityContext: &core_v1.PodSecurityContext{
+						SeccompProfile: &core_v1.SeccompProfile{
+							Type: core_v1.SeccompProfileTypeRuntimeDefault,
 						},
 					},
+					Volumes: volumes,
 				},
 			},
 		},
 	}
+	applyJobTemplate(&job.Spec.Template.Spec, r.Template)
+	applyOwnership(&job.Spec.Template.Spec, r.Ownership)
 	return job
 }
 
+// restrictedSecurityContext is the container security context for populator steps, they never need to gain
+// privileges so we always lock that down to keep restricted pod security happy
+func restrictedSecurityContext() *core_v1.SecurityContext {
+	allowPrivilegeEscalation := false
+	return &core_v1.SecurityContext{
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		Capabilities: &core_v1.Capabilities{
+			Drop: []core_v1.Capability{"ALL"},
+		},
+	}
+}
+
 // RunPopulatorJob kicks off a Kubernetes Job using the supplied k8s client, and Job Spec
 func RunPopulatorJob(c kubernetes.Interface, j *batch.Job, namespace string) (*batch.Job, error) {
-	jobClient := c.Batch().Jobs(namespace)
-	result, err := jobClient.Create(j)
+	jobClient := c.BatchV1().Jobs(namespace)
+	result, err := jobClient.Create(context.TODO(), j, metav1.CreateOptions{})
 	if err != nil {
 		log.Printf("error encountered launching job %s, %v", j.Name, err)
 	}
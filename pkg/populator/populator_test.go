@@ -0,0 +1,99 @@
+package populator
+
+import (
+	"testing"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildJobSpecLifecycle(t *testing.T) {
+	ttl, backoff, deadline := int32(300), int32(0), int64(60)
+	tests := []struct {
+		name          string
+		req           JobRequest
+		ttl, backoff  int32
+		deadline      int64
+		populatorName string
+	}{
+		{
+			name:     "controller defaults",
+			req:      JobRequest{Name: "job", PVCName: "pvc"},
+			ttl:      DefaultTTLSecondsAfterFinished,
+			backoff:  DefaultBackoffLimit,
+			deadline: DefaultActiveDeadlineSeconds,
+		},
+		{
+			name:          "request overrides",
+			req:           JobRequest{Name: "job", PVCName: "pvc", PopulatorName: "pop", TTLSecondsAfterFinished: &ttl, BackoffLimit: &backoff, ActiveDeadlineSeconds: &deadline},
+			ttl:           ttl,
+			backoff:       backoff,
+			deadline:      deadline,
+			populatorName: "pop",
+		},
+	}
+	for _, tt := range tests {
+		job := BuildJobSpec(&tt.req)
+		spec := job.Spec
+		if *spec.TTLSecondsAfterFinished != tt.ttl || *spec.BackoffLimit != tt.backoff || *spec.ActiveDeadlineSeconds != tt.deadline {
+			t.Errorf("%s: got ttl %d, backoff %d, deadline %d, want %d, %d, %d", tt.name,
+				*spec.TTLSecondsAfterFinished, *spec.BackoffLimit, *spec.ActiveDeadlineSeconds, tt.ttl, tt.backoff, tt.deadline)
+		}
+		if spec.Template.Spec.RestartPolicy != core_v1.RestartPolicyNever {
+			t.Errorf("%s: restart policy %s, the job controller wouldn't count failed pods", tt.name, spec.Template.Spec.RestartPolicy)
+		}
+		for _, labels := range []map[string]string{job.Labels, spec.Template.Labels} {
+			if labels[v1alpha1.LabelPVC] != "pvc" || labels[v1alpha1.LabelPopulator] != tt.populatorName {
+				t.Errorf("%s: unexpected labels %v", tt.name, labels)
+			}
+		}
+	}
+}
+
+func TestWithOverrides(t *testing.T) {
+	pop := &v1alpha1.Populator{
+		ObjectMeta: metav1.ObjectMeta{Name: "pop"},
+		Spec: v1alpha1.PopulatorSpec{
+			Type:        v1alpha1.TypeGit,
+			Git:         v1alpha1.GitPopulator{Repo: "https://example.com/repo.git", Branch: "master"},
+			Overridable: []string{v1alpha1.OverrideBranch},
+		},
+	}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		extra       *v1alpha1.Overrides
+		branch, tag string
+		wantErr     bool
+	}{
+		{name: "none", branch: "master"},
+		{name: "PVC branch", annotations: map[string]string{v1alpha1.AnnOverrideBranch: "develop"}, branch: "develop"},
+		{name: "request branch", extra: &v1alpha1.Overrides{Branch: "feature-x"}, branch: "feature-x"},
+		{
+			name:        "request after the PVC",
+			annotations: map[string]string{v1alpha1.AnnOverrideBranch: "develop"},
+			extra:       &v1alpha1.Overrides{Branch: "feature-x"},
+			branch:      "feature-x",
+		},
+		{name: "PVC tag not overridable", annotations: map[string]string{v1alpha1.AnnOverrideTag: "v1.0"}, wantErr: true},
+		{name: "request tag not overridable", extra: &v1alpha1.Overrides{Tag: "v1.0"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		pvc := &core_v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Annotations: tt.annotations}}
+		got, err := withOverrides(pvc, []*v1alpha1.Populator{pop}, tt.extra)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: withOverrides() = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if git := got[0].Spec.AllSources()[0].Git; git.Branch != tt.branch || git.Tag != tt.tag {
+			t.Errorf("%s: got branch %q tag %q, want %q %q", tt.name, git.Branch, git.Tag, tt.branch, tt.tag)
+		}
+	}
+	if pop.Spec.Git.Branch != "master" {
+		t.Errorf("withOverrides() changed the Populator it was given")
+	}
+}
@@ -0,0 +1,237 @@
+package populator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+)
+
+// PreflightTimeout bounds each pre-flight probe, a source that can't answer in this long is reported unreachable
+var PreflightTimeout = 10 * time.Second
+
+// PreflightDeniedNets are the ranges probes can't connect to unless PreflightAllowedNets lets them.  The defaults are
+// the private ranges (RFC 1918, carrier-grade NAT and IPv6 unique local addresses) most clusters take their pod and
+// service addresses from, the manager's -preflight-deny-cidrs adds a cluster's own ranges if it uses others
+var PreflightDeniedNets = mustParseCIDRs("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7")
+
+// PreflightAllowedNets are exceptions to PreflightDeniedNets, e.g. a git server on the private network.  The
+// manager's -preflight-allow-cidrs sets them
+var PreflightAllowedNets []*net.IPNet
+
+// ParseCIDRs parses a comma separated list of CIDRs, an empty string is an empty list
+func ParseCIDRs(s string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, cidr := range strings.Split(s, ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets, err := ParseCIDRs(strings.Join(cidrs, ","))
+	if err != nil {
+		panic(err)
+	}
+	return nets
+}
+
+func inNets(ip net.IP, nets []*net.IPNet) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// probeAllowed returns true if the pre-flight checks may connect to ip.  Loopback and link-local addresses (the
+// controller itself, cloud metadata endpoints) are never allowed, whatever PreflightAllowedNets says
+func probeAllowed(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	return !inNets(ip, PreflightDeniedNets) || inNets(ip, PreflightAllowedNets)
+}
+
+// The probes run in the controller with its network access, not the job's, and anyone who can create a Populator
+// picks where they go.  They're only allowed to reach addresses probeAllowed accepts, whatever the URL's host name
+// resolves to.  Redirects are dialled the same way, and nothing goes through a proxy since we couldn't tell where it
+// connects to
+func probeDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout: PreflightTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !probeAllowed(net.ParseIP(host)) {
+				return fmt.Errorf("pre-flight checks can't connect to %s", host)
+			}
+			return nil
+		},
+	}
+}
+
+// ProbeSources runs the pre-flight check for each of the Populator's own sources (not its includes, callers
+// probe each included Populator separately so the result lands on the right one).  Types that don't implement
+// SourceProber are assumed reachable, and so are sources with templates since they depend on the PVC (see
+// ResolvePopulators)
+func ProbeSources(p *v1alpha1.Populator) error {
+	p = p.DeepCopy()
+	p.Default()
+	var errs []string
+	for i, src := range p.Spec.AllSources() {
+		if sourceTemplated(&src) {
+			continue
+		}
+		t, _ := LookupType(src.Type)
+		prober, ok := t.(SourceProber)
+		if !ok {
+			continue
+		}
+		if err := prober.Probe(&src); err != nil {
+			errs = append(errs, fmt.Sprintf("source %d (%s): %v", i, src.Type, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// probeGit is the equivalent of `git ls-remote`: for http(s) repos we ask the smart HTTP endpoint for the refs
+// and make sure the branch or tag we're going to clone exists, for ssh and git protocol repos we can only check
+// that something is listening
+func probeGit(src *v1alpha1.Source) error {
+	if src.Git == nil || src.Git.Repo == "" {
+		return fmt.Errorf("no git repo")
+	}
+	ref := "refs/heads/" + src.Git.Branch
+	if src.Git.Tag != "" {
+		ref = "refs/tags/" + src.Git.Tag
+	}
+	u, err := url.Parse(src.Git.Repo)
+	if err != nil || u.Host == "" {
+		// scp style, user@host:path
+		if i := strings.Index(src.Git.Repo, ":"); i > 0 {
+			host := src.Git.Repo[:i]
+			return probeTCP(host[strings.LastIndex(host, "@")+1:], "22")
+		}
+		return fmt.Errorf("unable to parse repo URL %s", RedactURL(src.Git.Repo))
+	}
+	switch u.Scheme {
+	case "http", "https":
+	case "ssh":
+		return probeTCP(u.Hostname(), portOr(u, "22"))
+	case "git":
+		return probeTCP(u.Hostname(), portOr(u, "9418"))
+	default:
+		return fmt.Errorf("unsupported repo URL scheme %q", u.Scheme)
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/info/refs"
+	u.RawQuery = "service=git-upload-pack"
+	resp, err := httpClient().Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		// private (or, on some hosts, missing) repo, we can't tell without the credentials so let the job try
+		return nil
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%s returned %s", RedactURL(src.Git.Repo), resp.Status)
+	}
+	found, err := advertisesRef(resp.Body, ref)
+	if err != nil {
+		return fmt.Errorf("unable to read refs from %s: %v", RedactURL(src.Git.Repo), err)
+	}
+	if !found {
+		return fmt.Errorf("%s not found in %s", ref, RedactURL(src.Git.Repo))
+	}
+	return nil
+}
+
+// advertisesRef scans a git-upload-pack ref advertisement ("<pkt-len><sha> <ref>[\x00<caps>]" lines) for ref
+func advertisesRef(r io.Reader, ref string) (bool, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, 0); i >= 0 {
+			line = line[:i]
+		}
+		if strings.HasSuffix(line, " "+ref) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// probeS3 makes sure the bucket exists with an anonymous HEAD request, a 403 still means it's there
+func probeS3(src *v1alpha1.Source) error {
+	if src.S3 == nil || src.S3.Bucket == "" {
+		return fmt.Errorf("no bucket")
+	}
+	var bucketURL string
+	if src.S3.Endpoint != "" {
+		bucketURL = strings.TrimSuffix(src.S3.Endpoint, "/") + "/" + src.S3.Bucket
+	} else {
+		region := src.S3.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		bucketURL = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", src.S3.Bucket, region)
+	}
+	resp, err := httpClient().Head(bucketURL)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("bucket %s not found", src.S3.Bucket)
+	}
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("%s returned %s", RedactURL(bucketURL), resp.Status)
+	}
+	return nil
+}
+
+func probeTCP(host, port string) error {
+	conn, err := probeDialer().Dial("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func portOr(u *url.URL, port string) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+	return port
+}
+
+func httpClient() *http.Client {
+	return &http.Client{
+		Timeout:   PreflightTimeout,
+		Transport: &http.Transport{DialContext: probeDialer().DialContext},
+	}
+}
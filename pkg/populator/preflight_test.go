@@ -0,0 +1,72 @@
+package populator
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestProbeAllowed(t *testing.T) {
+	defer func(denied, allowed []*net.IPNet) {
+		PreflightDeniedNets, PreflightAllowedNets = denied, allowed
+	}(PreflightDeniedNets, PreflightAllowedNets)
+	PreflightDeniedNets = append(PreflightDeniedNets, mustParseCIDRs("203.0.113.0/24")...)
+	PreflightAllowedNets = mustParseCIDRs("10.1.2.0/24", "127.0.0.0/8", "169.254.0.0/16")
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "140.82.112.3", want: true},
+		{ip: "2606:4700::1111", want: true},
+		{ip: "127.0.0.1"},
+		{ip: "::1"},
+		{ip: "169.254.169.254"},
+		{ip: "fe80::1"},
+		{ip: "0.0.0.0"},
+		{ip: "10.96.0.1"},
+		{ip: "172.20.0.5"},
+		{ip: "192.168.1.1"},
+		{ip: "100.64.0.1"},
+		{ip: "fd00::1"},
+		{ip: "203.0.113.7"},
+		{ip: "10.1.2.3", want: true},
+	}
+	for _, tt := range tests {
+		if got := probeAllowed(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("probeAllowed(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+	if probeAllowed(nil) {
+		t.Errorf("probeAllowed() of a host name that isn't an address = true, want false")
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{in: ""},
+		{in: "10.0.0.0/8", want: []string{"10.0.0.0/8"}},
+		{in: " 10.0.0.0/8, fd00::/8 ,", want: []string{"10.0.0.0/8", "fd00::/8"}},
+		{in: "10.1.2.3/8", want: []string{"10.0.0.0/8"}},
+		{in: "10.0.0.1", wantErr: true},
+		{in: "10.0.0.0/8,nonsense", wantErr: true},
+	}
+	for _, tt := range tests {
+		nets, err := ParseCIDRs(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCIDRs(%q) = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		var got []string
+		for _, n := range nets {
+			got = append(got, n.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseCIDRs(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package populator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	clientset "github.com/j-griffith/populator/pkg/clientset/v1alpha1"
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PopulatorNamespace returns the namespace of the Populator the PVC's dataSource names, it's empty for a
+// ClusterPopulator
+func PopulatorNamespace(pvc *core_v1.PersistentVolumeClaim) string {
+	if v1alpha1.IsClusterPopulatorDataSource(pvc.Spec.DataSource) {
+		return ""
+	}
+	if ns := pvc.Annotations[v1alpha1.AnnPopulatorNamespace]; ns != "" {
+		return ns
+	}
+	return pvc.Namespace
+}
+
+// NamespacedGetter returns a PopulatorGetter for the Populators in namespace
+func NamespacedGetter(c clientset.Interface, namespace string) PopulatorGetter {
+	return func(name string) (*v1alpha1.Populator, error) {
+		return c.Populators(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	}
+}
+
+// ClusterGetter returns a PopulatorGetter for ClusterPopulators, they come back as Populators (see AsPopulator)
+func ClusterGetter(c clientset.Interface) PopulatorGetter {
+	return func(name string) (*v1alpha1.Populator, error) {
+		cp, err := c.ClusterPopulators().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return cp.AsPopulator(), nil
+	}
+}
+
+// DataSourceGetter returns the PopulatorGetter for the kind of Populator the PVC's dataSource names, the Populator
+// itself and everything it includes are fetched with it.  A ClusterPopulator can only include other
+// ClusterPopulators
+func DataSourceGetter(c clientset.Interface, pvc *core_v1.PersistentVolumeClaim) PopulatorGetter {
+	if v1alpha1.IsClusterPopulatorDataSource(pvc.Spec.DataSource) {
+		return ClusterGetter(c)
+	}
+	return NamespacedGetter(c, PopulatorNamespace(pvc))
+}
+
+// CheckGrants makes sure PVCs in namespace may use pops, Populators (and the ones they include) from another
+// namespace.  Every one of them needs a grant from grants, the PopulatorGrants in their namespace.  None of them
+// may use a secret or pick the service account or pull secrets the job runs with, all of those would be
+// looked up in the PVC's namespace on the Populator author's say so
+func CheckGrants(grants []v1alpha1.PopulatorGrant, namespace string, pops []*v1alpha1.Populator) error {
+	for _, pop := range pops {
+		// ClusterPopulators are there for every namespace to use
+		if pop.IsCluster() {
+			continue
+		}
+		granted := false
+		for i := range grants {
+			if grants[i].Allows(namespace, pop.Name) {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			return fmt.Errorf("no PopulatorGrant in namespace %s allows namespace %s to use Populator %s", pop.Namespace, namespace, pop.Name)
+		}
+		if pop.Spec.SecretRef != "" {
+			return fmt.Errorf("Populator %s/%s uses a secret, it can only be used in its own namespace", pop.Namespace, pop.Name)
+		}
+		if t := pop.Spec.JobTemplate; t != nil && (t.ServiceAccountName != "" || len(t.ImagePullSecrets) > 0) {
+			return fmt.Errorf("Populator %s/%s sets the job's service account or image pull secrets, it can only be used in its own namespace", pop.Namespace, pop.Name)
+		}
+	}
+	return nil
+}
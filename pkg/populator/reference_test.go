@@ -0,0 +1,54 @@
+package populator
+
+import (
+	"testing"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckGrants(t *testing.T) {
+	shared := func(name string) *v1alpha1.Populator {
+		return &v1alpha1.Populator{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shared"}}
+	}
+	withSecret := shared("private")
+	withSecret.Spec.SecretRef = "credentials"
+	withAccount := shared("account")
+	withAccount.Spec.JobTemplate = &v1alpha1.JobTemplate{ServiceAccountName: "builder"}
+	withPullSecret := shared("pull")
+	withPullSecret.Spec.JobTemplate = &v1alpha1.JobTemplate{ImagePullSecrets: []core_v1.LocalObjectReference{{Name: "registry"}}}
+	cluster := &v1alpha1.Populator{
+		TypeMeta:   metav1.TypeMeta{Kind: v1alpha1.ClusterKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "curated"},
+	}
+	grant := func(from string, to ...string) v1alpha1.PopulatorGrant {
+		g := v1alpha1.PopulatorGrant{Spec: v1alpha1.PopulatorGrantSpec{From: []v1alpha1.GrantFrom{{Namespace: from}}}}
+		for _, name := range to {
+			g.Spec.To = append(g.Spec.To, v1alpha1.GrantTo{Name: name})
+		}
+		return g
+	}
+	tests := []struct {
+		name    string
+		grants  []v1alpha1.PopulatorGrant
+		pops    []*v1alpha1.Populator
+		allowed bool
+	}{
+		{"granted", []v1alpha1.PopulatorGrant{grant("team-a", "base")}, []*v1alpha1.Populator{shared("base")}, true},
+		{"one of several grants", []v1alpha1.PopulatorGrant{grant("team-b"), grant("team-a", "base")}, []*v1alpha1.Populator{shared("base")}, true},
+		{"cluster populators need no grant", nil, []*v1alpha1.Populator{cluster}, true},
+		{"no grants", nil, []*v1alpha1.Populator{shared("base")}, false},
+		{"granted to another namespace", []v1alpha1.PopulatorGrant{grant("team-b")}, []*v1alpha1.Populator{shared("base")}, false},
+		{"include not granted", []v1alpha1.PopulatorGrant{grant("team-a", "base")}, []*v1alpha1.Populator{shared("tools"), shared("base")}, false},
+		{"uses a secret", []v1alpha1.PopulatorGrant{grant("team-a")}, []*v1alpha1.Populator{withSecret}, false},
+		{"sets the service account", []v1alpha1.PopulatorGrant{grant("team-a")}, []*v1alpha1.Populator{withAccount}, false},
+		{"sets image pull secrets", []v1alpha1.PopulatorGrant{grant("team-a")}, []*v1alpha1.Populator{withPullSecret}, false},
+	}
+	for _, tt := range tests {
+		err := CheckGrants(tt.grants, "team-a", tt.pops)
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("%s: CheckGrants() = %v, want allowed %v", tt.name, err, tt.allowed)
+		}
+	}
+}
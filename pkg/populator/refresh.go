@@ -0,0 +1,17 @@
+package populator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// EnvRefresh is set to "true" on the source steps of a refresh job, the destination already holds an earlier
+// population and the step should update it in place (git fetch and checkout, copy only what's changed...) rather
+// than fail because it isn't empty
+const EnvRefresh = "POPULATOR_REFRESH"
+
+// shortHash returns enough of the hash of s to tell refresh jobs apart while keeping their names short
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:8]
+}
@@ -0,0 +1,18 @@
+package populator
+
+import (
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateSchedule checks a refresh schedule parses the way the controller's RefreshScheduler will parse it.  It
+// lives here rather than with the API types so they don't need the cron library
+func ValidateSchedule(schedule string, fldPath *field.Path) field.ErrorList {
+	if schedule == "" {
+		return nil
+	}
+	if _, err := cron.ParseStandard(schedule); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, schedule, err.Error())}
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package populator
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+
+	batch "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Credentials never go in a step's args or plain env values, where anyone who can read the job would see them.
+// A step lists the keys of its source's secret it needs (JobStep.SecretKeys) and BuildJobSpec resolves each of
+// them into an env var the kubelet reads from the secret, or a file in a volume mounted into that step alone.
+// Keys a step doesn't ask for never reach it
+const (
+	SecretMountPath = "/populator-secrets"
+	EnvSecretDir    = "POPULATOR_SECRET_DIR"
+)
+
+// secretFileMode makes the secret's files read only.  They're owned by root (and the pod's fsGroup if it has one),
+// so a step running as any other user without an fsGroup could only read them if everyone can.  Nobody else sees
+// them anyway, the volume is only mounted into the step that uses them
+var secretFileMode int32 = 0444
+
+// SecretKey is a key of the step's secret the step needs, exactly one of Env and Path is set.  Env passes the
+// value as that environment variable, Path projects it as a file at that path under EnvSecretDir.  An Optional
+// key that isn't in the secret is left out, otherwise the step won't start without it
+type SecretKey struct {
+	Key      string
+	Env      string
+	Path     string
+	Optional bool
+}
+
+// ClusterSecretNamespace is where the secrets ClusterPopulators name are kept, the manager's
+// -cluster-secret-namespace flag sets it.  A job can only use secrets in its own namespace, so each job gets a copy
+// of the ones it needs which is deleted along with the job
+var ClusterSecretNamespace = metav1.NamespaceDefault
+
+// secretCopy is a secret to copy from ClusterSecretNamespace into the job's namespace, only the keys the job's
+// steps use are copied
+type secretCopy struct {
+	from string
+	to   string
+	keys []string
+}
+
+// clusterSecretName is the name of jobName's copy of a ClusterPopulator secret
+func clusterSecretName(jobName, secretRef string) string {
+	return jobName + "-" + secretRef
+}
+
+// copySecrets copies the secrets into the job's namespace, they're owned by the job so the garbage collector
+// cleans them up when it goes.  The job can't start without them, its pods wait until they're there
+func copySecrets(c kubernetes.Interface, job *batch.Job, copies []*secretCopy) error {
+	isController := true
+	owner := metav1.OwnerReference{
+		APIVersion: batch.SchemeGroupVersion.String(),
+		Kind:       "Job",
+		Name:       job.Name,
+		UID:        job.UID,
+		Controller: &isController,
+	}
+	for _, cp := range copies {
+		src, err := c.CoreV1().Secrets(ClusterSecretNamespace).Get(context.TODO(), cp.from, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("unable to fetch secret %s/%s: %v", ClusterSecretNamespace, cp.from, err)
+		}
+		dst := &core_v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            cp.to,
+				Namespace:       job.Namespace,
+				Labels:          map[string]string{"app": "populator"},
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+			Type: src.Type,
+			Data: map[string][]byte{},
+		}
+		for _, key := range cp.keys {
+			if v, ok := src.Data[key]; ok {
+				dst.Data[key] = v
+			}
+		}
+		secrets := c.CoreV1().Secrets(job.Namespace)
+		_, err = secrets.Create(context.TODO(), dst, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			// left over from an earlier job with the same name that hasn't been cleaned up yet, anything else with
+			// the name isn't ours to overwrite
+			var existing *core_v1.Secret
+			existing, err = secrets.Get(context.TODO(), cp.to, metav1.GetOptions{})
+			if err == nil && !isSecretCopy(existing, job.Name) {
+				return fmt.Errorf("secret %s/%s already exists and wasn't copied there by the controller", job.Namespace, cp.to)
+			}
+			if err == nil {
+				dst.ResourceVersion = existing.ResourceVersion
+				_, err = secrets.Update(context.TODO(), dst, metav1.UpdateOptions{})
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("unable to copy secret %s/%s into namespace %s: %v", ClusterSecretNamespace, cp.from, job.Namespace, err)
+		}
+	}
+	return nil
+}
+
+// isSecretCopy returns true if secret is a copy copySecrets made for a job named jobName
+func isSecretCopy(secret *core_v1.Secret, jobName string) bool {
+	if secret.Labels["app"] != "populator" {
+		return false
+	}
+	for _, ref := range secret.OwnerReferences {
+		if ref.Kind == "Job" && ref.Name == jobName {
+			return true
+		}
+	}
+	return false
+}
+
+// secretKeysUsed returns the keys of secret the steps ask for, sorted
+func secretKeysUsed(steps []JobStep, secret string) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, s := range steps {
+		if s.Secret != secret {
+			continue
+		}
+		for _, k := range s.SecretKeys {
+			if !seen[k.Key] {
+				seen[k.Key] = true
+				keys = append(keys, k.Key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stepSecrets resolves the step's SecretKeys, it returns the env vars read from the secret and the volume holding
+// the keys it wants as files, or nil if there aren't any.  Required and optional files are projected separately
+// since optional only applies to a whole projection
+func stepSecrets(s JobStep, volumeName string) ([]core_v1.EnvVar, *core_v1.Volume) {
+	if s.Secret == "" {
+		return nil, nil
+	}
+	var env []core_v1.EnvVar
+	var required, optional []core_v1.KeyToPath
+	for _, k := range s.SecretKeys {
+		if k.Env != "" {
+			isOptional := k.Optional
+			env = append(env, core_v1.EnvVar{
+				Name: k.Env,
+				ValueFrom: &core_v1.EnvVarSource{
+					SecretKeyRef: &core_v1.SecretKeySelector{
+						LocalObjectReference: core_v1.LocalObjectReference{Name: s.Secret},
+						Key:                  k.Key,
+						Optional:             &isOptional,
+					},
+				},
+			})
+			continue
+		}
+		item := core_v1.KeyToPath{Key: k.Key, Path: k.Path}
+		if k.Optional {
+			optional = append(optional, item)
+		} else {
+			required = append(required, item)
+		}
+	}
+	if len(required) == 0 && len(optional) == 0 {
+		return env, nil
+	}
+	var sources []core_v1.VolumeProjection
+	project := func(items []core_v1.KeyToPath, isOptional bool) {
+		if len(items) == 0 {
+			return
+		}
+		sources = append(sources, core_v1.VolumeProjection{
+			Secret: &core_v1.SecretProjection{
+				LocalObjectReference: core_v1.LocalObjectReference{Name: s.Secret},
+				Items:                items,
+				Optional:             &isOptional,
+			},
+		})
+	}
+	project(required, false)
+	project(optional, true)
+	mode := secretFileMode
+	return env, &core_v1.Volume{
+		Name: volumeName,
+		VolumeSource: core_v1.VolumeSource{
+			Projected: &core_v1.ProjectedVolumeSource{
+				Sources:     sources,
+				DefaultMode: &mode,
+			},
+		},
+	}
+}
+
+// RedactURL strips the credentials from a URL so it can be logged or reported, the user name of an ssh or git URL
+// is left alone since it's not a secret (it's usually just git)
+func RedactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	if _, hasPassword := u.User.Password(); !hasPassword && u.Scheme != "http" && u.Scheme != "https" {
+		return s
+	}
+	u.User = url.User("redacted")
+	return u.String()
+}
@@ -0,0 +1,159 @@
+package populator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	batch "k8s.io/api/batch/v1"
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStepSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		step     JobStep
+		env      []string // names of the env vars
+		required []string // paths of the required and optional files
+		optional []string
+	}{
+		{name: "no secret", step: JobStep{SecretKeys: []SecretKey{{Key: "token", Env: "TOKEN"}}}},
+		{name: "no keys", step: JobStep{Secret: "creds"}},
+		{
+			name: "env only",
+			step: JobStep{Secret: "creds", SecretKeys: []SecretKey{{Key: "token", Env: "TOKEN"}, {Key: "user", Env: "USER", Optional: true}}},
+			env:  []string{"TOKEN", "USER"},
+		},
+		{
+			name:     "files",
+			step:     JobStep{Secret: "creds", SecretKeys: []SecretKey{{Key: "ssh-key", Path: "id_rsa"}, {Key: "known-hosts", Path: "known_hosts"}}},
+			required: []string{"id_rsa", "known_hosts"},
+		},
+		{
+			name: "env, required and optional files",
+			step: JobStep{Secret: "creds", SecretKeys: []SecretKey{
+				{Key: "token", Env: "TOKEN"},
+				{Key: "ssh-key", Path: "id_rsa"},
+				{Key: "known-hosts", Path: "known_hosts", Optional: true},
+			}},
+			env:      []string{"TOKEN"},
+			required: []string{"id_rsa"},
+			optional: []string{"known_hosts"},
+		},
+	}
+	for _, tt := range tests {
+		env, volume := stepSecrets(tt.step, "secrets")
+
+		var names []string
+		for _, e := range env {
+			names = append(names, e.Name)
+			ref := e.ValueFrom.SecretKeyRef
+			if ref.Name != tt.step.Secret {
+				t.Errorf("%s: env %s reads secret %s, want %s", tt.name, e.Name, ref.Name, tt.step.Secret)
+			}
+		}
+		if !reflect.DeepEqual(names, tt.env) {
+			t.Errorf("%s: env %v, want %v", tt.name, names, tt.env)
+		}
+
+		if tt.required == nil && tt.optional == nil {
+			if volume != nil {
+				t.Errorf("%s: got volume %+v, want none", tt.name, volume)
+			}
+			continue
+		}
+		if volume == nil || volume.Projected == nil {
+			t.Errorf("%s: got volume %+v, want a projected volume", tt.name, volume)
+			continue
+		}
+		// a step running as a user other than root and without an fsGroup has to be able to read them
+		if mode := volume.Projected.DefaultMode; mode == nil || *mode&0004 == 0 {
+			t.Errorf("%s: files have mode %v, want them readable by any user", tt.name, mode)
+		}
+		var required, optional []string
+		for _, src := range volume.Projected.Sources {
+			for _, item := range src.Secret.Items {
+				if *src.Secret.Optional {
+					optional = append(optional, item.Path)
+				} else {
+					required = append(required, item.Path)
+				}
+			}
+		}
+		if !reflect.DeepEqual(required, tt.required) || !reflect.DeepEqual(optional, tt.optional) {
+			t.Errorf("%s: files %v and optional %v, want %v and %v", tt.name, required, optional, tt.required, tt.optional)
+		}
+	}
+}
+
+func TestSecretKeysUsed(t *testing.T) {
+	steps := []JobStep{
+		{Secret: "creds", SecretKeys: []SecretKey{{Key: "token", Env: "TOKEN"}, {Key: "ssh-key", Path: "id_rsa"}}},
+		{Secret: "other", SecretKeys: []SecretKey{{Key: "password", Env: "PASSWORD"}}},
+		{Secret: "creds", SecretKeys: []SecretKey{{Key: "token", Env: "GIT_TOKEN"}}},
+		{},
+	}
+	if got, want := secretKeysUsed(steps, "creds"), []string{"ssh-key", "token"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("secretKeysUsed() = %v, want %v", got, want)
+	}
+	if got := secretKeysUsed(steps, "missing"); got != nil {
+		t.Errorf("secretKeysUsed() of an unused secret = %v, want none", got)
+	}
+}
+
+func TestCopySecrets(t *testing.T) {
+	job := &batch.Job{ObjectMeta: metav1.ObjectMeta{Name: "pop-pvc-data", Namespace: "team-a", UID: "1234"}}
+	src := &core_v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: ClusterSecretNamespace},
+		Data:       map[string][]byte{"token": []byte("t"), "unused": []byte("u")},
+	}
+	copyName := clusterSecretName(job.Name, "creds")
+	stale := &core_v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:            copyName,
+		Namespace:       "team-a",
+		Labels:          map[string]string{"app": "populator"},
+		OwnerReferences: []metav1.OwnerReference{{Kind: "Job", Name: job.Name, UID: "old"}},
+	}}
+	foreign := &core_v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: copyName, Namespace: "team-a"},
+		Data:       map[string][]byte{"token": []byte("theirs")},
+	}
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		wantErr bool
+	}{
+		{name: "copied", objects: []runtime.Object{src}},
+		{name: "replaces an earlier job's copy", objects: []runtime.Object{src, stale}},
+		{name: "leaves other secrets alone", objects: []runtime.Object{src, foreign}, wantErr: true},
+		{name: "no secret to copy", wantErr: true},
+	}
+	for _, tt := range tests {
+		c := fake.NewClientset(tt.objects...)
+		err := copySecrets(c, job, []*secretCopy{{from: "creds", to: copyName, keys: []string{"token"}}})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: copySecrets() = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		got, getErr := c.CoreV1().Secrets("team-a").Get(context.TODO(), copyName, metav1.GetOptions{})
+		if err != nil {
+			if getErr == nil && string(got.Data["token"]) != "theirs" {
+				t.Errorf("%s: secret that wasn't ours was overwritten with %v", tt.name, got.Data)
+			}
+			continue
+		}
+		if getErr != nil {
+			t.Errorf("%s: no copy: %v", tt.name, getErr)
+			continue
+		}
+		if !reflect.DeepEqual(got.Data, map[string][]byte{"token": []byte("t")}) {
+			t.Errorf("%s: copied %v, want only the token", tt.name, got.Data)
+		}
+		if !isSecretCopy(got, job.Name) || got.OwnerReferences[0].UID != job.UID {
+			t.Errorf("%s: copy isn't owned by the job: %+v", tt.name, got.ObjectMeta)
+		}
+	}
+}
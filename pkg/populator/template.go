@@ -0,0 +1,190 @@
+package populator
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// specData is what Populator field templates are rendered with.  Source fields may be Go templates using the
+// metadata of the PVC being populated, so one Populator can serve many teams, e.g. a bucket prefix of
+// "{{ .PVC.Namespace }}/" or a branch of "{{ .PVC.Labels.team }}".  The templated fields are the git repo, branch
+// and tag, the s3 bucket, prefix, endpoint and region, the subpath and parameter values.  A missing label or
+// annotation is an error rather than an empty string
+type specData struct {
+	PVC pvcData
+}
+
+type pvcData struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// Templated returns true if any of the Populator's sources use templates, each PVC then gets its own data so
+// a golden volume can't be shared between them
+func Templated(p *v1alpha1.Populator) bool {
+	for _, src := range p.Spec.AllSources() {
+		if sourceTemplated(&src) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderPopulators renders the templated fields of each Populator for pvc, Populators without templates are
+// returned as they are
+func renderPopulators(pvc *core_v1.PersistentVolumeClaim, pops []*v1alpha1.Populator) ([]*v1alpha1.Populator, error) {
+	data := specData{PVC: pvcData{
+		Name:        pvc.Name,
+		Namespace:   pvc.Namespace,
+		Labels:      pvc.Labels,
+		Annotations: pvc.Annotations,
+	}}
+	rendered := make([]*v1alpha1.Populator, 0, len(pops))
+	for _, pop := range pops {
+		if !Templated(pop) {
+			rendered = append(rendered, pop)
+			continue
+		}
+		pop = pop.DeepCopy()
+		sources := pop.Spec.AllSources()
+		for i := range sources {
+			if err := renderSource(&sources[i], data); err != nil {
+				return nil, fmt.Errorf("Populator %s source %d: %v", pop.Name, i, err)
+			}
+			// rendering can produce anything, the result has to pass the same checks as a literal value
+			fldPath := field.NewPath("spec", "sources").Index(i)
+			if errs := append(sources[i].Validate(fldPath), validateSource(&sources[i], fldPath)...); len(errs) > 0 {
+				return nil, fmt.Errorf("Populator %s rendered for PVC %s: %v", pop.Name, pvc.Name, errs.ToAggregate())
+			}
+		}
+		// two subpaths that only differ once rendered can't be told apart by validation
+		if errs := v1alpha1.ValidateSubpaths(sources, field.NewPath("spec", "sources")); len(errs) > 0 {
+			return nil, fmt.Errorf("Populator %s rendered for PVC %s: %v", pop.Name, pvc.Name, errs.ToAggregate())
+		}
+		// the single source form's fields are rewritten as a list of one, it populates the same way
+		pop.Spec.Type = ""
+		pop.Spec.Sources = sources
+		rendered = append(rendered, pop)
+	}
+	return rendered, nil
+}
+
+// templatedField is one of a source's string fields that may hold a template, path is its path in the source
+type templatedField struct {
+	path  []string
+	value *string
+}
+
+func (f templatedField) name() string {
+	return strings.Join(f.path, ".")
+}
+
+// templatedFields returns the source's string fields that may hold templates, parameters aside
+func templatedFields(src *v1alpha1.Source) []templatedField {
+	fields := []templatedField{{[]string{"subpath"}, &src.Subpath}}
+	if src.Git != nil {
+		fields = append(fields,
+			templatedField{[]string{"git", "repo"}, &src.Git.Repo},
+			templatedField{[]string{"git", "branch"}, &src.Git.Branch},
+			templatedField{[]string{"git", "tag"}, &src.Git.Tag})
+	}
+	if src.S3 != nil {
+		fields = append(fields,
+			templatedField{[]string{"s3", "bucket"}, &src.S3.Bucket},
+			templatedField{[]string{"s3", "prefix"}, &src.S3.Prefix},
+			templatedField{[]string{"s3", "endpoint"}, &src.S3.Endpoint},
+			templatedField{[]string{"s3", "region"}, &src.S3.Region})
+	}
+	return fields
+}
+
+func sourceTemplated(src *v1alpha1.Source) bool {
+	for _, f := range templatedFields(src) {
+		if isTemplate(*f.value) {
+			return true
+		}
+	}
+	for _, v := range src.Parameters {
+		if isTemplate(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderSource renders the source's templated fields in place, it has to come from a copy of the Populator
+func renderSource(src *v1alpha1.Source, data specData) error {
+	for _, f := range templatedFields(src) {
+		v, err := renderField(f.name(), *f.value, data)
+		if err != nil {
+			return err
+		}
+		*f.value = v
+	}
+	if src.Parameters != nil {
+		params := make(map[string]string, len(src.Parameters))
+		for k, v := range src.Parameters {
+			rendered, err := renderField("parameters."+k, v, data)
+			if err != nil {
+				return err
+			}
+			params[k] = rendered
+		}
+		src.Parameters = params
+	}
+	return nil
+}
+
+func renderField(name, value string, data specData) (string, error) {
+	if !isTemplate(value) {
+		return value, nil
+	}
+	tmpl, err := parseField(name, value)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func parseField(name, value string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Parse(value)
+}
+
+func isTemplate(s string) bool {
+	return strings.Contains(s, "{{")
+}
+
+// validateTemplates checks the source's templates parse, fldPath is the path of the object holding its type and
+// source block
+func validateTemplates(src *v1alpha1.Source, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, f := range templatedFields(src) {
+		if !isTemplate(*f.value) {
+			continue
+		}
+		if _, err := parseField(f.name(), *f.value); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(f.path[0], f.path[1:]...), *f.value, err.Error()))
+		}
+	}
+	for k, v := range src.Parameters {
+		if !isTemplate(v) {
+			continue
+		}
+		if _, err := parseField(k, v); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("parameters").Key(k), v, err.Error()))
+		}
+	}
+	return allErrs
+}
@@ -0,0 +1,177 @@
+package populator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	core_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestRenderPopulators(t *testing.T) {
+	pvc := &core_v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+		Name:        "data",
+		Namespace:   "team-a",
+		Labels:      map[string]string{"team": "blue", "branch": "-x"},
+		Annotations: map[string]string{"example.com/dataset": "2024"},
+	}}
+	gitPopulator := func(repo, branch, subpath string) *v1alpha1.Populator {
+		return &v1alpha1.Populator{
+			ObjectMeta: metav1.ObjectMeta{Name: "pop"},
+			Spec: v1alpha1.PopulatorSpec{
+				Type:       v1alpha1.TypeGit,
+				Mountpoint: "/data",
+				Subpath:    subpath,
+				Git:        v1alpha1.GitPopulator{Repo: repo, Branch: branch},
+			},
+		}
+	}
+	tests := []struct {
+		name    string
+		pop     *v1alpha1.Populator
+		want    []v1alpha1.Source
+		wantErr bool
+	}{
+		{
+			name: "labels, namespace and name",
+			pop:  gitPopulator("https://example.com/{{ .PVC.Namespace }}.git", "{{ .PVC.Labels.team }}", "{{ .PVC.Name }}"),
+			want: []v1alpha1.Source{{
+				Type:    v1alpha1.TypeGit,
+				Subpath: "data",
+				Git:     &v1alpha1.GitPopulator{Repo: "https://example.com/team-a.git", Branch: "blue"},
+			}},
+		},
+		{
+			name: "annotations and parameters",
+			pop: &v1alpha1.Populator{
+				ObjectMeta: metav1.ObjectMeta{Name: "pop"},
+				Spec: v1alpha1.PopulatorSpec{Mountpoint: "/data", Sources: []v1alpha1.Source{{
+					Type:       v1alpha1.TypeS3,
+					S3:         &v1alpha1.S3Populator{Bucket: "datasets", Prefix: `{{ index .PVC.Annotations "example.com/dataset" }}/`},
+					Parameters: map[string]string{"owner": "{{ .PVC.Labels.team }}", "fixed": "yes"},
+				}}},
+			},
+			want: []v1alpha1.Source{{
+				Type:       v1alpha1.TypeS3,
+				S3:         &v1alpha1.S3Populator{Bucket: "datasets", Prefix: "2024/"},
+				Parameters: map[string]string{"owner": "blue", "fixed": "yes"},
+			}},
+		},
+		{
+			name:    "missing label",
+			pop:     gitPopulator("https://example.com/repo.git", "{{ .PVC.Labels.missing }}", ""),
+			wantErr: true,
+		},
+		{
+			name:    "doesn't parse",
+			pop:     gitPopulator("https://example.com/repo.git", "{{ .PVC.Labels.team ", ""),
+			wantErr: true,
+		},
+		{
+			name:    "renders to a ref git would take as an option",
+			pop:     gitPopulator("https://example.com/repo.git", "{{ .PVC.Labels.branch }}", ""),
+			wantErr: true,
+		},
+		{
+			name: "renders to a subpath another source uses",
+			pop: &v1alpha1.Populator{
+				ObjectMeta: metav1.ObjectMeta{Name: "pop"},
+				Spec: v1alpha1.PopulatorSpec{Mountpoint: "/data", Sources: []v1alpha1.Source{
+					{Type: v1alpha1.TypeGit, Subpath: "data/src", Git: &v1alpha1.GitPopulator{Repo: "https://example.com/a.git", Branch: "master"}},
+					{Type: v1alpha1.TypeGit, Subpath: "{{ .PVC.Name }}", Git: &v1alpha1.GitPopulator{Repo: "https://example.com/b.git", Branch: "master"}},
+				}},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "renders to a subpath outside the mountpoint",
+			pop:     gitPopulator("https://example.com/repo.git", "master", "../{{ .PVC.Name }}"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		orig := tt.pop.DeepCopy()
+		rendered, err := renderPopulators(pvc, []*v1alpha1.Populator{tt.pop})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: renderPopulators() = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(tt.pop, orig) {
+			t.Errorf("%s: renderPopulators() changed the Populator it was given", tt.name)
+		}
+		if err != nil {
+			continue
+		}
+		if got := rendered[0].Spec; got.Type != "" || !reflect.DeepEqual(got.Sources, tt.want) {
+			t.Errorf("%s: renderPopulators() gave type %q and sources %+v, want %+v", tt.name, got.Type, got.Sources, tt.want)
+		}
+	}
+}
+
+func TestRenderPopulatorsUntemplated(t *testing.T) {
+	pop := &v1alpha1.Populator{Spec: v1alpha1.PopulatorSpec{
+		Type: v1alpha1.TypeGit,
+		Git:  v1alpha1.GitPopulator{Repo: "https://example.com/repo.git", Branch: "master"},
+	}}
+	rendered, err := renderPopulators(&core_v1.PersistentVolumeClaim{}, []*v1alpha1.Populator{pop})
+	if err != nil {
+		t.Fatalf("renderPopulators() = %v", err)
+	}
+	if rendered[0] != pop {
+		t.Errorf("renderPopulators() copied a Populator without templates")
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	tests := []struct {
+		name string
+		src  v1alpha1.Source
+		errs int
+	}{
+		{"no templates", v1alpha1.Source{Subpath: "src", Git: &v1alpha1.GitPopulator{Repo: "https://example.com/repo.git"}}, 0},
+		{"good template", v1alpha1.Source{Git: &v1alpha1.GitPopulator{Branch: "{{ .PVC.Labels.team }}"}}, 0},
+		{"bad template", v1alpha1.Source{Git: &v1alpha1.GitPopulator{Branch: "{{ .PVC.Labels.team "}}, 1},
+		{"bad subpath and parameter", v1alpha1.Source{Subpath: "{{ end }}", Parameters: map[string]string{"url": "{{ if }}"}}, 2},
+	}
+	for _, tt := range tests {
+		if errs := validateTemplates(&tt.src, field.NewPath("spec")); len(errs) != tt.errs {
+			t.Errorf("%s: got %d errors (%v), want %d", tt.name, len(errs), errs, tt.errs)
+		}
+	}
+}
+
+func TestResolvePopulators(t *testing.T) {
+	pvc := &core_v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+		Name:        "data",
+		Namespace:   "team-a",
+		Annotations: map[string]string{v1alpha1.AnnOverrideBranch: "release"},
+	}}
+	base := &v1alpha1.Populator{Spec: v1alpha1.PopulatorSpec{
+		Type: v1alpha1.TypeGit,
+		Git:  v1alpha1.GitPopulator{Repo: "https://example.com/base.git", Branch: "master"},
+	}}
+	pop := &v1alpha1.Populator{
+		ObjectMeta: metav1.ObjectMeta{Name: "pop"},
+		Spec: v1alpha1.PopulatorSpec{
+			Type:        v1alpha1.TypeGit,
+			Git:         v1alpha1.GitPopulator{Repo: "https://example.com/{{ .PVC.Namespace }}.git", Branch: "master"},
+			Overridable: []string{v1alpha1.OverrideBranch},
+		},
+	}
+	resolved, err := ResolvePopulators(pvc, []*v1alpha1.Populator{base, pop}, nil)
+	if err != nil {
+		t.Fatalf("ResolvePopulators() = %v", err)
+	}
+	if len(resolved) != 2 || resolved[0] != base {
+		t.Fatalf("ResolvePopulators() = %v, want the base Populator left as it is", resolved)
+	}
+	src := resolved[1].Spec.AllSources()[0]
+	if src.Git.Repo != "https://example.com/team-a.git" || src.Git.Branch != "release" {
+		t.Errorf("ResolvePopulators() gave repo %s branch %s, want the rendered repo and the PVC's branch", src.Git.Repo, src.Git.Branch)
+	}
+	if pop.Spec.Git.Branch != "master" {
+		t.Errorf("ResolvePopulators() changed the Populator it was given")
+	}
+}
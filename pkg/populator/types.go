@@ -0,0 +1,161 @@
+package populator
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// PopulatorType knows how to populate one kind of source, the built in git and s3 types are registered by this
+// package and anything importing it can register more with RegisterType
+type PopulatorType interface {
+	// Name is the value of the type field in the Populator (or source) that selects this type
+	Name() string
+	// Validate checks the type specific settings of src, fldPath is the path of the object holding them
+	Validate(src *v1alpha1.Source, fldPath *field.Path) field.ErrorList
+	// BuildStep returns the job step that writes src where opts says, the step's name is filled in by the caller
+	BuildStep(src *v1alpha1.Source, opts StepOptions) (*JobStep, error)
+	// ParseResult decodes the termination message of a finished step, it returns nil if the step didn't report
+	// a result
+	ParseResult(message string) (*StepResult, error)
+}
+
+// StepOptions is what a PopulatorType needs to know about the job a source is populated by
+type StepOptions struct {
+	Dest      string // Directory the source is written to (mountpoint plus subpath)
+	SecretRef string // Secret in the job's namespace with the source credentials, may be empty (see JobStep.SecretKeys)
+	CacheDir  string // Where the download cache is mounted, empty if there isn't one
+	// Path is where the source is in its Populator, for error messages.  It's nil if the caller doesn't know
+	Path *field.Path
+	// Refresh is set when Dest has already been populated and should be brought up to date in place (see
+	// EnvRefresh), types that can't do that should clear Dest and populate it from scratch
+	Refresh bool
+}
+
+// SourceProber is implemented by PopulatorTypes that support the pre-flight check.  Probe returns an error
+// describing what's wrong (unknown host, missing branch, missing bucket...) or nil if the source looks usable.
+// Probes run in the controller so they should be cheap, they aren't a substitute for the job succeeding
+type SourceProber interface {
+	Probe(src *v1alpha1.Source) error
+}
+
+var (
+	typesMu  sync.RWMutex
+	registry = map[string]PopulatorType{}
+	classes  = map[string]PopulatorType{} // see SetClass
+)
+
+func init() {
+	RegisterType(gitType{})
+	RegisterType(s3Type{})
+}
+
+// RegisterType makes t available to Populators, replacing any type already registered under the same name
+// (including the built in ones).  It's normally called from an init function
+func RegisterType(t PopulatorType) {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	registry[t.Name()] = t
+}
+
+// LookupType returns the registered type with the given name, falling back to a PopulatorClass of that name
+func LookupType(name string) (PopulatorType, bool) {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	if t, ok := registry[name]; ok {
+		return t, true
+	}
+	t, ok := classes[name]
+	return t, ok
+}
+
+// RegisteredTypes returns the names of every registered type and PopulatorClass, sorted
+func RegisteredTypes() []string {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	names := make([]string, 0, len(registry)+len(classes))
+	for name := range registry {
+		names = append(names, name)
+	}
+	for name := range classes {
+		if _, ok := registry[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate is Populator.Validate plus the checks of each source's type, it's what the admission webhook runs
+func Validate(p *v1alpha1.Populator) field.ErrorList {
+	allErrs := p.Validate()
+	specPath := field.NewPath("spec")
+	allErrs = append(allErrs, ValidateSchedule(p.Spec.RefreshSchedule, specPath.Child("refresh_schedule"))...)
+	if len(p.Spec.Sources) == 0 {
+		// the single source form keeps its fields directly in the spec
+		for _, src := range p.Spec.AllSources() {
+			allErrs = append(allErrs, validateSource(&src, specPath)...)
+		}
+		return allErrs
+	}
+	for i := range p.Spec.Sources {
+		srcPath := specPath.Child("sources").Index(i)
+		allErrs = append(allErrs, validateSource(&p.Spec.Sources[i], srcPath)...)
+		// the built in s3 type can't build a step yet, a list of sources with one in it could never populate
+		if t, ok := LookupType(p.Spec.Sources[i].Type); ok {
+			if _, builtin := t.(s3Type); builtin {
+				allErrs = append(allErrs, field.Forbidden(srcPath.Child("type"), "the s3 type isn't implemented yet and can't be used in sources"))
+			}
+		}
+	}
+	return allErrs
+}
+
+// Warnings returns what p uses that's deprecated, none of it stops p from working yet.  The admission webhook hands
+// them back to the client and the controller records them as events on the PVCs p populates
+func Warnings(p *v1alpha1.Populator) []string {
+	var warnings []string
+	for i, src := range p.Spec.AllSources() {
+		if hasURLCredentials(&src) {
+			warnings = append(warnings, fmt.Sprintf("%s: credentials in the repo URL are deprecated and will be rejected in a future release, move them to the secret named by secret_ref",
+				sourcePath(&p.Spec, i).Child("git", "repo")))
+		}
+	}
+	return warnings
+}
+
+func validateSource(src *v1alpha1.Source, fldPath *field.Path) field.ErrorList {
+	if src.Type == "" {
+		// already reported by Populator.Validate
+		return nil
+	}
+	t, ok := LookupType(src.Type)
+	if !ok {
+		return field.ErrorList{field.NotSupported(fldPath.Child("type"), src.Type, RegisteredTypes())}
+	}
+	return append(t.Validate(src, fldPath), validateTemplates(src, fldPath)...)
+}
+
+// typeOfStep works out the type of a source step from the EnvStepType in its environment, hooks and the
+// ownership step don't have one
+func typeOfStep(c core_v1.Container) (PopulatorType, bool) {
+	for _, env := range c.Env {
+		if env.Name == EnvStepType {
+			return LookupType(env.Value)
+		}
+	}
+	return nil, false
+}
+
+// sourcePath returns the path of spec's i'th source for error messages, the single source form keeps its fields
+// directly in the spec
+func sourcePath(spec *v1alpha1.PopulatorSpec, i int) *field.Path {
+	if len(spec.Sources) == 0 {
+		return field.NewPath("spec")
+	}
+	return field.NewPath("spec", "sources").Index(i)
+}
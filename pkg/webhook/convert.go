@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	"github.com/j-griffith/populator/pkg/api/types/v1beta1"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ConvertPath is where the CRD conversion webhook is served
+const ConvertPath = "/convert"
+
+// serveConvert handles ConversionReviews from the API server for the Populator CRD
+func serveConvert(w http.ResponseWriter, r *http.Request) {
+	review := apiextensions.ConversionReview{}
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		http.Error(w, fmt.Sprintf("unable to decode ConversionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "ConversionReview has no request", http.StatusBadRequest)
+		return
+	}
+
+	resp := &apiextensions.ConversionResponse{
+		UID:    review.Request.UID,
+		Result: metav1.Status{Status: metav1.StatusSuccess},
+	}
+	for _, obj := range review.Request.Objects {
+		converted, err := convertPopulator(obj.Raw, review.Request.DesiredAPIVersion)
+		if err != nil {
+			log.Printf("conversion to %s failed: %v", review.Request.DesiredAPIVersion, err)
+			resp.ConvertedObjects = nil
+			resp.Result = metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}
+			break
+		}
+		resp.ConvertedObjects = append(resp.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+
+	review.Request = nil
+	review.Response = resp
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&review); err != nil {
+		log.Printf("unable to encode ConversionReview response: %v", err)
+	}
+}
+
+// convertPopulator converts a single serialized Populator to the desired apiVersion
+func convertPopulator(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, err
+	}
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	switch {
+	case typeMeta.APIVersion == v1alpha1.SchemeGroupVersion.String() && desiredAPIVersion == v1beta1.SchemeGroupVersion.String():
+		in, out := &v1alpha1.Populator{}, &v1beta1.Populator{}
+		if err := json.Unmarshal(raw, in); err != nil {
+			return nil, err
+		}
+		if err := v1beta1.ConvertFromV1alpha1(in, out); err != nil {
+			return nil, err
+		}
+		return json.Marshal(out)
+	case typeMeta.APIVersion == v1beta1.SchemeGroupVersion.String() && desiredAPIVersion == v1alpha1.SchemeGroupVersion.String():
+		in, out := &v1beta1.Populator{}, &v1alpha1.Populator{}
+		if err := json.Unmarshal(raw, in); err != nil {
+			return nil, err
+		}
+		if err := v1beta1.ConvertToV1alpha1(in, out); err != nil {
+			return nil, err
+		}
+		return json.Marshal(out)
+	}
+	return nil, fmt.Errorf("unsupported conversion from %s to %s", typeMeta.APIVersion, desiredAPIVersion)
+}
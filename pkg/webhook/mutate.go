@@ -0,0 +1,41 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	admission "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// mutatePopulator applies the API defaults to a Populator (or a ClusterPopulator, they share a spec), we only
+// patch the fields that actually changed so we don't stomp on anything we don't know about
+func (s *Server) mutatePopulator(req *admission.AdmissionRequest) ([]patchOperation, error) {
+	if req.Operation != admission.Create && req.Operation != admission.Update {
+		return nil, nil
+	}
+	p := &v1alpha1.Populator{}
+	if err := json.Unmarshal(req.Object.Raw, p); err != nil {
+		return nil, fmt.Errorf("unable to decode Populator: %v", err)
+	}
+
+	defaulted := p.DeepCopy()
+	defaulted.Default()
+
+	// "add" replaces the value if the member already exists, so it covers both cases
+	var patch []patchOperation
+	if defaulted.Spec.Mountpoint != p.Spec.Mountpoint {
+		patch = append(patch, patchOperation{Op: "add", Path: "/spec/mountpoint", Value: defaulted.Spec.Mountpoint})
+	}
+	if defaulted.Spec.Image != p.Spec.Image {
+		patch = append(patch, patchOperation{Op: "add", Path: "/spec/image", Value: defaulted.Spec.Image})
+	}
+	if p.Spec.Type != "" && defaulted.Spec.Git != p.Spec.Git {
+		patch = append(patch, patchOperation{Op: "add", Path: "/spec/git", Value: defaulted.Spec.Git})
+	}
+	if !equality.Semantic.DeepEqual(defaulted.Spec.Sources, p.Spec.Sources) {
+		patch = append(patch, patchOperation{Op: "add", Path: "/spec/sources", Value: defaulted.Spec.Sources})
+	}
+	return patch, nil
+}
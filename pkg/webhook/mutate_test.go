@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	admission "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMutatePopulator(t *testing.T) {
+	ttl := int32(60)
+	tests := []struct {
+		name      string
+		operation admission.Operation
+		spec      v1alpha1.PopulatorSpec
+		paths     []string
+	}{
+		{
+			name:      "everything defaulted",
+			operation: admission.Create,
+			spec:      v1alpha1.PopulatorSpec{Type: v1alpha1.TypeGit, Git: v1alpha1.GitPopulator{Repo: "https://example.com/repo.git"}},
+			paths:     []string{"/spec/mountpoint", "/spec/image", "/spec/git"},
+		},
+		{
+			name:      "mountpoint normalized",
+			operation: admission.Update,
+			spec: v1alpha1.PopulatorSpec{Type: v1alpha1.TypeGit, Mountpoint: "src/", Image: "my/git", TTLSecondsAfterFinished: &ttl,
+				Git: v1alpha1.GitPopulator{Repo: "https://example.com/repo.git", Tag: "v1"}},
+			paths: []string{"/spec/mountpoint"},
+		},
+		{
+			name:      "nothing to do",
+			operation: admission.Create,
+			spec: v1alpha1.PopulatorSpec{Type: v1alpha1.TypeGit, Mountpoint: "/src", Image: "my/git", TTLSecondsAfterFinished: &ttl,
+				Git: v1alpha1.GitPopulator{Repo: "https://example.com/repo.git", Branch: "main"}},
+		},
+		{
+			name:      "s3 has no default image",
+			operation: admission.Create,
+			spec:      v1alpha1.PopulatorSpec{Type: v1alpha1.TypeS3, Mountpoint: "/src", TTLSecondsAfterFinished: &ttl, S3: &v1alpha1.S3Populator{Bucket: "b"}},
+		},
+		{
+			name:      "deletes are left alone",
+			operation: admission.Delete,
+			spec:      v1alpha1.PopulatorSpec{Type: v1alpha1.TypeGit},
+		},
+	}
+	s := &Server{}
+	for _, tt := range tests {
+		p := &v1alpha1.Populator{ObjectMeta: metav1.ObjectMeta{Name: "pop", Namespace: "ns"}, Spec: tt.spec}
+		req := &admission.AdmissionRequest{Operation: tt.operation, Namespace: "ns", Object: rawObject(t, p)}
+		patch, err := s.mutatePopulator(req)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		var paths []string
+		for _, op := range patch {
+			if op.Op != "add" {
+				t.Errorf("%s: unexpected %s of %s", tt.name, op.Op, op.Path)
+			}
+			paths = append(paths, op.Path)
+		}
+		if !reflect.DeepEqual(paths, tt.paths) {
+			t.Errorf("%s: patched %v, want %v", tt.name, paths, tt.paths)
+		}
+	}
+}
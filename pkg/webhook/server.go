@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	clientset "github.com/j-griffith/populator/pkg/clientset/v1alpha1"
+	admission "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Paths the webhooks are served on, these need to match the webhook configurations in kubernetes/deploy
+const (
+	ValidatePopulatorPath = "/validate-populator"
+	ValidatePVCPath       = "/validate-pvc"
+	MutatePopulatorPath   = "/mutate-populator"
+)
+
+// Server serves the admission webhooks for Populators and the PVCs that reference them, plus the CRD conversion
+// webhook, over TLS
+type Server struct {
+	Addr            string
+	CertFile        string
+	KeyFile         string
+	KubeClient      kubernetes.Interface
+	PopulatorClient clientset.Interface
+}
+
+// admitFunc inspects an admission request and returns an error describing why it should be rejected, or nil
+// to let it through.  Mutating webhooks also return the JSON patch to apply, validating ones just return nil
+type admitFunc func(req *admission.AdmissionRequest) ([]patchOperation, error)
+
+// warnFunc returns warnings to pass back to the client with an admission request that's allowed, they're shown by
+// kubectl but don't stop anything
+type warnFunc func(req *admission.AdmissionRequest) []string
+
+// patchOperation is a single RFC 6902 JSON patch operation
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Run serves the webhooks until stopCh is closed
+func (s *Server) Run(stopCh <-chan struct{}) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ValidatePopulatorPath, serve(s.validatePopulator, s.populatorWarnings))
+	mux.HandleFunc(ValidatePVCPath, serve(s.validatePVC))
+	mux.HandleFunc(MutatePopulatorPath, serve(s.mutatePopulator))
+	mux.HandleFunc(ConvertPath, serveConvert)
+
+	srv := &http.Server{Addr: s.Addr, Handler: mux}
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	log.Printf("serving admission webhooks on %s", s.Addr)
+	if err := srv.ListenAndServeTLS(s.CertFile, s.KeyFile); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// serve wraps an admitFunc with the AdmissionReview decoding and encoding, warn (if any) adds warnings to requests
+// that are allowed
+func serve(admit admitFunc, warn ...warnFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		review := admission.AdmissionReview{}
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			http.Error(w, fmt.Sprintf("unable to decode AdmissionReview: %v", err), http.StatusBadRequest)
+			return
+		}
+		if review.Request == nil {
+			http.Error(w, "AdmissionReview has no request", http.StatusBadRequest)
+			return
+		}
+
+		resp := &admission.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+		patch, err := admit(review.Request)
+		if err != nil {
+			log.Printf("rejecting %s %s/%s: %v", review.Request.Kind.Kind, review.Request.Namespace, review.Request.Name, err)
+			resp.Allowed = false
+			resp.Result = &metav1.Status{
+				Status:  metav1.StatusFailure,
+				Reason:  metav1.StatusReasonInvalid,
+				Code:    http.StatusUnprocessableEntity,
+				Message: err.Error(),
+			}
+		} else {
+			for _, f := range warn {
+				resp.Warnings = append(resp.Warnings, f(review.Request)...)
+			}
+			if len(patch) > 0 {
+				raw, err := json.Marshal(patch)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("unable to encode patch: %v", err), http.StatusInternalServerError)
+					return
+				}
+				patchType := admission.PatchTypeJSONPatch
+				resp.Patch = raw
+				resp.PatchType = &patchType
+			}
+		}
+
+		review.Request = nil
+		review.Response = resp
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(&review); err != nil {
+			log.Printf("unable to encode AdmissionReview response: %v", err)
+		}
+	}
+}
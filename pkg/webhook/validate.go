@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	"github.com/j-griffith/populator/pkg/populator"
+	admission "k8s.io/api/admission/v1"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// validatePopulator rejects Populators (and ClusterPopulators) that could never be used to populate a PVC
+func (s *Server) validatePopulator(req *admission.AdmissionRequest) ([]patchOperation, error) {
+	if req.Operation == admission.Delete {
+		return nil, nil
+	}
+	p := &v1alpha1.Populator{}
+	if err := json.Unmarshal(req.Object.Raw, p); err != nil {
+		return nil, fmt.Errorf("unable to decode Populator: %v", err)
+	}
+
+	allErrs := populator.Validate(p)
+	// a ClusterPopulator's secret lives in the controller's namespace and it can only include other
+	// ClusterPopulators
+	secretNamespace, get := req.Namespace, populator.NamespacedGetter(s.PopulatorClient, req.Namespace)
+	if req.Kind.Kind == v1alpha1.ClusterKind {
+		secretNamespace, get = populator.ClusterSecretNamespace, populator.ClusterGetter(s.PopulatorClient)
+	}
+	if p.Spec.SecretRef != "" {
+		_, err := s.KubeClient.CoreV1().Secrets(secretNamespace).Get(context.TODO(), p.Spec.SecretRef, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			allErrs = append(allErrs, field.NotFound(field.NewPath("spec", "secret_ref"), p.Spec.SecretRef))
+		} else if err != nil {
+			// we can't tell either way, don't block the user because the API server hiccuped
+			log.Printf("unable to check secret %s/%s for Populator %s: %v", secretNamespace, p.Spec.SecretRef, p.Name, err)
+		}
+	}
+	// includes don't have to exist yet (kubectl apply of a directory creates things in any order), but they
+	// mustn't loop back on themselves
+	_, err := populator.ResolveIncludes(func(name string) (*v1alpha1.Populator, error) {
+		included, err := get(name)
+		if errors.IsNotFound(err) {
+			return &v1alpha1.Populator{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+		}
+		return included, err
+	}, p)
+	if cycle, ok := err.(*populator.IncludeCycleError); ok {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "include"), p.Spec.Include, cycle.Error()))
+	} else if err != nil {
+		log.Printf("unable to check includes for Populator %s: %v", p.Name, err)
+	}
+	return nil, allErrs.ToAggregate()
+}
+
+// populatorWarnings returns the deprecation warnings for the Populator being created or updated
+func (s *Server) populatorWarnings(req *admission.AdmissionRequest) []string {
+	if req.Operation == admission.Delete {
+		return nil
+	}
+	p := &v1alpha1.Populator{}
+	if err := json.Unmarshal(req.Object.Raw, p); err != nil {
+		return nil
+	}
+	return populator.Warnings(p)
+}
+
+// validatePVC rejects PVCs whose dataSource names a Populator that doesn't exist (or that they aren't granted
+// the use of), the PVC would otherwise be created and just sit there empty
+func (s *Server) validatePVC(req *admission.AdmissionRequest) ([]patchOperation, error) {
+	if req.Operation != admission.Create {
+		return nil, nil
+	}
+	pvc := &core_v1.PersistentVolumeClaim{}
+	if err := json.Unmarshal(req.Object.Raw, pvc); err != nil {
+		return nil, fmt.Errorf("unable to decode PersistentVolumeClaim: %v", err)
+	}
+	if !v1alpha1.IsPopulatorDataSource(pvc.Spec.DataSource) {
+		return nil, nil
+	}
+
+	if errs := populator.ValidateSchedule(pvc.Annotations[v1alpha1.AnnRefreshSchedule], field.NewPath("metadata", "annotations").Key(v1alpha1.AnnRefreshSchedule)); len(errs) > 0 {
+		return nil, errs.ToAggregate()
+	}
+
+	pvc.Namespace = req.Namespace
+	ns, name := populator.PopulatorNamespace(pvc), pvc.Spec.DataSource.Name
+	pop, err := populator.DataSourceGetter(s.PopulatorClient, pvc)(name)
+	if errors.IsNotFound(err) {
+		if ns == "" {
+			return nil, fmt.Errorf("spec.dataSource: ClusterPopulator %s not found", name)
+		}
+		return nil, fmt.Errorf("spec.dataSource: Populator %s not found in namespace %s", name, ns)
+	} else if err != nil {
+		log.Printf("unable to check %s %s for PVC %s: %v", pvc.Spec.DataSource.Kind, name, pvc.Name, err)
+		return nil, nil
+	}
+	// the Populators it includes are checked by the controller, they need a lookup each.  ClusterPopulators
+	// don't need a grant
+	if ns != "" && ns != req.Namespace {
+		grants, err := s.PopulatorClient.PopulatorGrants(ns).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			log.Printf("unable to check PopulatorGrants in namespace %s for PVC %s: %v", ns, pvc.Name, err)
+			return nil, nil
+		}
+		if err := populator.CheckGrants(grants.Items, req.Namespace, []*v1alpha1.Populator{pop}); err != nil {
+			return nil, fmt.Errorf("spec.dataSource: %v", err)
+		}
+	}
+	// overrides the Populator doesn't allow would only fail when the controller builds the job
+	if o := v1alpha1.OverridesFromAnnotations(pvc.Annotations); o != nil {
+		if err := pop.Spec.CheckOverridable(o); err != nil {
+			return nil, fmt.Errorf("metadata.annotations: %v", err)
+		}
+		if err := o.Apply(&pop.Spec); err != nil {
+			return nil, fmt.Errorf("metadata.annotations: %v", err)
+		}
+	}
+	return nil, nil
+}
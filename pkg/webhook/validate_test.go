@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/j-griffith/populator/pkg/api/types/v1alpha1"
+	clientset "github.com/j-griffith/populator/pkg/clientset/v1alpha1"
+	admission "k8s.io/api/admission/v1"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakePopulators is just enough of the Populator clientset for the webhooks, anything it doesn't override
+// panics through the nil embedded interfaces
+type fakePopulators struct {
+	clientset.Interface
+	clientset.PopulatorInterface
+	pops map[string]*v1alpha1.Populator
+}
+
+func (f *fakePopulators) Populators(namespace string) clientset.PopulatorInterface {
+	return f
+}
+
+func (f *fakePopulators) Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1alpha1.Populator, error) {
+	if p, ok := f.pops[name]; ok {
+		return p, nil
+	}
+	return nil, errors.NewNotFound(schema.GroupResource{Group: v1alpha1.GroupName, Resource: "populators"}, name)
+}
+
+func rawObject(t *testing.T, obj interface{}) runtime.RawExtension {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("unable to encode %T: %v", obj, err)
+	}
+	return runtime.RawExtension{Raw: raw}
+}
+
+func TestValidatePopulator(t *testing.T) {
+	secret := &core_v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "ns"}}
+	valid := v1alpha1.PopulatorSpec{
+		Type:       v1alpha1.TypeGit,
+		Mountpoint: "/data",
+		Git:        v1alpha1.GitPopulator{Repo: "https://example.com/repo.git"},
+	}
+	tests := []struct {
+		name      string
+		operation admission.Operation
+		spec      func(*v1alpha1.PopulatorSpec)
+		wantErr   string
+	}{
+		{"valid", admission.Create, func(s *v1alpha1.PopulatorSpec) {}, ""},
+		{"existing secret", admission.Create, func(s *v1alpha1.PopulatorSpec) { s.SecretRef = "creds" }, ""},
+		{"missing secret", admission.Update, func(s *v1alpha1.PopulatorSpec) { s.SecretRef = "nope" }, "spec.secret_ref"},
+		{"relative mountpoint", admission.Create, func(s *v1alpha1.PopulatorSpec) { s.Mountpoint = "data" }, "spec.mountpoint"},
+		{"no repo", admission.Create, func(s *v1alpha1.PopulatorSpec) { s.Git.Repo = "" }, "spec.git.repo"},
+		{"s3 without bucket", admission.Create, func(s *v1alpha1.PopulatorSpec) { s.Type = v1alpha1.TypeS3 }, "spec.s3.bucket"},
+		{"unknown type", admission.Create, func(s *v1alpha1.PopulatorSpec) { s.Type = "ftp" }, "spec.type"},
+		{"delete skips validation", admission.Delete, func(s *v1alpha1.PopulatorSpec) { s.Mountpoint = "" }, ""},
+	}
+	s := &Server{KubeClient: fake.NewClientset(secret)}
+	for _, tt := range tests {
+		p := &v1alpha1.Populator{ObjectMeta: metav1.ObjectMeta{Name: "pop", Namespace: "ns"}, Spec: valid}
+		tt.spec(&p.Spec)
+		req := &admission.AdmissionRequest{Operation: tt.operation, Namespace: "ns", Object: rawObject(t, p)}
+		_, err := s.validatePopulator(req)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: got error %v, want one mentioning %s", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidatePVC(t *testing.T) {
+	group := v1alpha1.GroupName
+	otherGroup := "snapshot.storage.k8s.io"
+	tests := []struct {
+		name      string
+		operation admission.Operation
+		ds        *core_v1.TypedLocalObjectReference
+		wantErr   bool
+	}{
+		{"no dataSource", admission.Create, nil, false},
+		{"existing Populator", admission.Create, &core_v1.TypedLocalObjectReference{APIGroup: &group, Kind: v1alpha1.Kind, Name: "pop"}, false},
+		{"missing Populator", admission.Create, &core_v1.TypedLocalObjectReference{APIGroup: &group, Kind: v1alpha1.Kind, Name: "nope"}, true},
+		{"someone else's dataSource", admission.Create, &core_v1.TypedLocalObjectReference{APIGroup: &otherGroup, Kind: "VolumeSnapshot", Name: "nope"}, false},
+		{"updates aren't checked", admission.Update, &core_v1.TypedLocalObjectReference{APIGroup: &group, Kind: v1alpha1.Kind, Name: "nope"}, false},
+	}
+	s := &Server{PopulatorClient: &fakePopulators{pops: map[string]*v1alpha1.Populator{"pop": {}}}}
+	for _, tt := range tests {
+		pvc := &core_v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc", Namespace: "ns"}}
+		pvc.Spec.DataSource = tt.ds
+		req := &admission.AdmissionRequest{Operation: tt.operation, Namespace: "ns", Object: rawObject(t, pvc)}
+		if _, err := s.validatePVC(req); (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}